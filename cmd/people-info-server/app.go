@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Gustcat/people-info-service/internal/config"
+	"github.com/Gustcat/people-info-service/internal/enrichment"
+	"github.com/Gustcat/people-info-service/internal/enrichment/batch"
+	"github.com/Gustcat/people-info-service/internal/enrichment/cache"
+	"github.com/Gustcat/people-info-service/internal/enrichment/dataset"
+	"github.com/Gustcat/people-info-service/internal/enrichment/provider"
+	"github.com/Gustcat/people-info-service/internal/enrichment/quota"
+	"github.com/Gustcat/people-info-service/internal/enrichment/reenrich"
+	"github.com/Gustcat/people-info-service/internal/enrichment/worker"
+	"github.com/Gustcat/people-info-service/internal/http-server/handlers/admin"
+	"github.com/Gustcat/people-info-service/internal/http-server/handlers/persons"
+	"github.com/Gustcat/people-info-service/internal/lib/audit"
+	"github.com/Gustcat/people-info-service/internal/lib/breaker"
+	"github.com/Gustcat/people-info-service/internal/lib/cursor"
+	"github.com/Gustcat/people-info-service/internal/lib/etag"
+	"github.com/Gustcat/people-info-service/internal/lib/httpclient"
+	"github.com/Gustcat/people-info-service/internal/retention"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+// storage хранилище, с которым работают обработчики и фоновое обогащение
+type storage interface {
+	persons.Creator
+	persons.PendingCreator
+	persons.Getter
+	persons.Lister
+	persons.Searcher
+	persons.Updater
+	persons.Deleter
+	persons.NationalitiesGetter
+	persons.HistoryGetter
+	persons.Restorer
+	persons.EnrichmentQueue
+	retention.Store
+	reenrich.Store
+	worker.Store
+	cache.Store
+	quota.Store
+}
+
+// newRouter собирает источники обогащения и маршруты сервиса и запускает фоновые
+// обогащение и очистку удаленных профилей, которые работают до отмены ctx.
+func newRouter(ctx context.Context, log *slog.Logger, conf *config.Config, store storage) (http.Handler, error) {
+	breakers := breaker.NewGroup(conf.Enrichment.Breaker.FailureThreshold, conf.Enrichment.Breaker.OpenTimeout)
+	enrichmentCache := cache.New(log, store, conf.Enrichment.Cache.TTL, conf.Enrichment.Cache.NegativeTTL, conf.Enrichment.Cache.Size)
+	quotaTracker := quota.New(log, store, conf.Enrichment.Quota.DailyBudget, conf.Enrichment.Quota.MinRemaining)
+	if err := quotaTracker.Load(ctx); err != nil {
+		log.Error("doesn't load enrichment quota usage", slog.String("error", err.Error()))
+	}
+
+	var enrichers []enrichment.Enricher
+	if conf.Enrichment.Mode == config.EnrichmentModeOffline {
+		ds, err := dataset.Load(conf.Enrichment.DatasetPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading enrichment dataset failed: %w", err)
+		}
+		log.Info("Enrichment dataset loaded", slog.Int("names", ds.Len()))
+		enrichers = ds.Enrichers()
+	} else {
+		enrichers = newEnrichers(ctx, log, conf.Enrichment, breakers, quotaTracker)
+		if conf.Enrichment.Cache.Enabled {
+			for i, e := range enrichers {
+				enrichers[i] = enrichmentCache.Wrap(e)
+			}
+		}
+	}
+	enricher := enrichment.NewRegistry(log, enrichers...)
+	setThresholds(enricher, conf.Enrichment.Thresholds)
+
+	// фоновое обогащение работает и в синхронном режиме: оно дообогащает профили,
+	// сохраненные без обогащения из-за исчерпанной квоты
+	async := conf.Enrichment.Async
+	pool := worker.New(log, store, enricher, worker.Options{
+		Workers:      async.Workers,
+		BatchSize:    async.BatchSize,
+		PollInterval: async.PollInterval,
+		Lease:        async.Lease,
+		MaxAttempts:  async.MaxAttempts,
+		RetryDelay:   async.RetryDelay,
+	})
+	pool.SetBudget(quotaTracker)
+	go pool.Run(ctx)
+
+	createHandler := persons.Create(ctx, log, store, enricher, quotaTracker)
+	if async.Enabled {
+		createHandler = persons.CreateAsync(ctx, log, store)
+	}
+
+	reenricher := reenrich.New(log, store, enricher)
+
+	if conf.Retention.Period > 0 {
+		purger := retention.New(log, store, conf.Retention.Period, conf.Retention.PurgeInterval)
+		go purger.Run(ctx)
+	}
+
+	cursors, err := newCursorSigner(log, conf.Pagination.CursorSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug("Try to setup router")
+	router := chi.NewRouter()
+
+	router.Use(middleware.Recoverer)
+
+	router.Get("/swagger/*", httpSwagger.WrapHandler)
+
+	router.Route("/api/v1/persons", func(r chi.Router) {
+		r.Use(audit.Middleware)
+
+		r.Post("/", createHandler)
+		r.Get("/", persons.List(ctx, log, store, cursors))
+		r.Get("/search", persons.Search(ctx, log, store, conf.Search.MinScore))
+		r.Get("/{id}", persons.GetByID(ctx, log, store))
+		r.Group(func(r chi.Router) {
+			if conf.HTTPServer.RequireIfMatch {
+				r.Use(etag.Require)
+			}
+			r.Patch("/{id}", persons.Update(ctx, log, store))
+			r.Delete("/{id}", persons.Delete(ctx, log, store))
+		})
+		r.Post("/{id}/restore", persons.Restore(ctx, log, store))
+		r.Post("/enrich", persons.ReenrichMany(ctx, log, store))
+		r.Post("/{id}/enrich", persons.Reenrich(ctx, log, reenricher))
+		r.Get("/{id}/nationalities", persons.Nationalities(ctx, log, store))
+		r.Get("/{id}/history", persons.History(ctx, log, store))
+	})
+
+	router.Route("/api/v1/admin", func(r chi.Router) {
+		r.Get("/enrichment/cache", admin.CacheStats(ctx, log, enrichmentCache))
+		r.Get("/enrichment/breakers", admin.Breakers(ctx, log, breakers))
+		r.Get("/enrichment/quota", admin.Quota(ctx, log, quotaTracker))
+	})
+
+	return router, nil
+}
+
+// newCursorSigner подписывает курсоры пагинации ключом secret или, если он не задан, случайным ключом
+func newCursorSigner(log *slog.Logger, secret string) (*cursor.Signer, error) {
+	if secret != "" {
+		return cursor.NewSigner([]byte(secret)), nil
+	}
+
+	log.Warn("PAGINATION_CURSOR_SECRET is not set, cursors will expire on restart")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating cursor key failed: %w", err)
+	}
+
+	return cursor.NewSigner(key), nil
+}
+
+func newEnrichers(ctx context.Context, log *slog.Logger, conf config.Enrichment, breakers *breaker.Group, tracker *quota.Tracker) []enrichment.Enricher {
+	enrichers := make([]enrichment.Enricher, 0, 3)
+
+	retry := httpclient.Options{
+		MaxRetries: conf.Retry.MaxRetries,
+		BaseDelay:  conf.Retry.BaseDelay,
+		MaxDelay:   conf.Retry.MaxDelay,
+	}
+	newClient := func(name string, p config.EnrichmentProvider) *httpclient.Client {
+		client := &http.Client{
+			Timeout:   p.Timeout,
+			Transport: tracker.Transport(name, http.DefaultTransport),
+		}
+		return httpclient.New(client, breakers.Get(name), retry)
+	}
+	add := func(e enrichment.BatchEnricher) {
+		if conf.Batch.Enabled {
+			enrichers = append(enrichers, batch.New(ctx, log, e, conf.Batch.Window, conf.Batch.Size))
+			return
+		}
+		enrichers = append(enrichers, e)
+	}
+
+	if p := conf.Agify; p.Enabled {
+		add(provider.NewAgify(newClient(provider.AgifyName, p), p.URL, p.APIKey))
+	}
+	if p := conf.Genderize; p.Enabled {
+		add(provider.NewGenderize(newClient(provider.GenderizeName, p), p.URL, p.APIKey))
+	}
+	if p := conf.Nationalize; p.Enabled {
+		add(provider.NewNationalize(newClient(provider.NationalizeName, p), p.URL, p.APIKey))
+	}
+
+	return enrichers
+}
+
+func setThresholds(registry *enrichment.Registry, conf config.EnrichmentThresholds) {
+	registry.SetThreshold(enrichment.AttributeAge, enrichment.Threshold{
+		MinProbability: conf.AgeMinProbability,
+		MinCount:       conf.AgeMinCount,
+	})
+	registry.SetThreshold(enrichment.AttributeGender, enrichment.Threshold{
+		MinProbability: conf.GenderMinProbability,
+		MinCount:       conf.GenderMinCount,
+	})
+	registry.SetThreshold(enrichment.AttributeNationality, enrichment.Threshold{
+		MinProbability: conf.NationalityMinProbability,
+		MinCount:       conf.NationalityMinCount,
+	})
+}
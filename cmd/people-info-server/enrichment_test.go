@@ -0,0 +1,658 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/config"
+	"github.com/Gustcat/people-info-service/internal/enrichment/cache"
+	"github.com/Gustcat/people-info-service/internal/enrichment/mockserver"
+	"github.com/Gustcat/people-info-service/internal/enrichment/quota"
+	"github.com/Gustcat/people-info-service/internal/lib/breaker"
+	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/Gustcat/people-info-service/internal/models"
+	"github.com/Gustcat/people-info-service/internal/repository/postgres/postgrestest"
+)
+
+// testApp сервис, собранный как в main, с хранилищем в памяти и имитацией публичных API
+type testApp struct {
+	t     *testing.T
+	api   *mockserver.Server
+	store *memStorage
+	srv   *httptest.Server
+}
+
+func newTestApp(t *testing.T, env map[string]string) *testApp {
+	t.Helper()
+
+	store := newMemStorage()
+	app := startTestApp(t, env, store)
+	app.store = store
+	return app
+}
+
+// newPostgresTestApp сервис с хранилищем в тестовой базе Postgres для проверок, зависящих
+// от SQL: фильтров, сортировки, курсоров и поиска. Без базы тест пропускается.
+func newPostgresTestApp(t *testing.T, env map[string]string) *testApp {
+	t.Helper()
+
+	return startTestApp(t, env, postgrestest.New(t))
+}
+
+func startTestApp(t *testing.T, env map[string]string, store storage) *testApp {
+	t.Helper()
+
+	api := mockserver.New()
+	t.Cleanup(api.Close)
+
+	vars := map[string]string{
+		"ENRICHMENT_AGIFY_URL":           api.URL(mockserver.Agify),
+		"ENRICHMENT_GENDERIZE_URL":       api.URL(mockserver.Genderize),
+		"ENRICHMENT_NATIONALIZE_URL":     api.URL(mockserver.Nationalize),
+		"ENRICHMENT_AGIFY_TIMEOUT":       "1s",
+		"ENRICHMENT_GENDERIZE_TIMEOUT":   "1s",
+		"ENRICHMENT_NATIONALIZE_TIMEOUT": "1s",
+		"ENRICHMENT_RETRY_BASE_DELAY":    "1ms",
+		"ENRICHMENT_RETRY_MAX_DELAY":     "50ms",
+		"ENRICHMENT_CACHE_ENABLED":       "false",
+		"ENRICHMENT_BATCH_ENABLED":       "false",
+	}
+	for k, v := range env {
+		vars[k] = v
+	}
+	for k, v := range vars {
+		t.Setenv(k, v)
+	}
+
+	conf, err := config.New()
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router, err := newRouter(ctx, log, conf, store)
+	if err != nil {
+		t.Fatalf("router: %v", err)
+	}
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	return &testApp{t: t, api: api, srv: srv}
+}
+
+func (a *testApp) do(method, path, body string) *http.Response {
+	a.t.Helper()
+
+	req, err := http.NewRequest(method, a.srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		a.t.Fatalf("request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		a.t.Fatalf("%s %s: %v", method, path, err)
+	}
+
+	return resp
+}
+
+func decode[T any](t *testing.T, resp *http.Response) *T {
+	t.Helper()
+	defer resp.Body.Close()
+
+	var body response.Response[T]
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if body.Data == nil {
+		t.Fatalf("response without data: status %d, error %q", resp.StatusCode, body.Error)
+	}
+
+	return body.Data
+}
+
+// create создает профиль и возвращает его вместе с происхождением атрибутов
+func (a *testApp) create(body string) *models.FullPerson {
+	a.t.Helper()
+
+	resp := a.do(http.MethodPost, "/api/v1/persons/", body)
+	if resp.StatusCode != http.StatusCreated {
+		a.t.Fatalf("create: status %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	id := decode[models.Identifier](a.t, resp).ID
+
+	return a.get(id)
+}
+
+func (a *testApp) get(id int64) *models.FullPerson {
+	a.t.Helper()
+
+	resp := a.do(http.MethodGet, "/api/v1/persons/"+itoa(id)+"?include=provenance", "")
+	if resp.StatusCode != http.StatusOK {
+		a.t.Fatalf("get: status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	return decode[models.FullPerson](a.t, resp)
+}
+
+func itoa(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+func scriptIvan(api *mockserver.Server) {
+	api.Handle(mockserver.Agify, "Ivan", mockserver.Age(42, 1200))
+	api.Handle(mockserver.Genderize, "Ivan", mockserver.Gender("male", 0.99, 1500))
+	api.Handle(mockserver.Nationalize, "Ivan", mockserver.Nationality(3000,
+		mockserver.Country{CountryID: "RU", Probability: 0.61},
+		mockserver.Country{CountryID: "UA", Probability: 0.2}))
+}
+
+func TestCreateEnrichesPerson(t *testing.T) {
+	app := newTestApp(t, nil)
+	scriptIvan(app.api)
+
+	person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+
+	if person.Age == nil || *person.Age != 42 {
+		t.Errorf("age = %v, want 42", person.Age)
+	}
+	if person.Gender == nil || *person.Gender != "male" {
+		t.Errorf("gender = %v, want male", person.Gender)
+	}
+	if person.Nationality == nil || *person.Nationality != "RU" {
+		t.Errorf("nationality = %v, want RU", person.Nationality)
+	}
+	if person.EnrichmentStatus != models.EnrichmentStatusDone {
+		t.Errorf("status = %q, want %q", person.EnrichmentStatus, models.EnrichmentStatusDone)
+	}
+
+	age := person.Provenance["age"]
+	if age == nil || age.Source != "agify" || age.CountryID == nil || *age.CountryID != "RU" {
+		t.Errorf("age provenance = %+v, want agify localized to RU", age)
+	}
+
+	resp := app.do(http.MethodGet, "/api/v1/persons/"+itoa(person.ID)+"/nationalities", "")
+	candidates := decode[[]models.NationalityCandidate](t, resp)
+	if len(*candidates) != 2 {
+		t.Errorf("got %d nationality candidates, want 2", len(*candidates))
+	}
+}
+
+func TestCreateLocalizesAgeAndGender(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		country string
+	}{
+		{name: "resolved nationality", body: `{"name":"Ivan","surname":"Petrov"}`, country: "RU"},
+		{name: "client hint", body: `{"name":"Ivan","surname":"Petrov","country_hint":"KZ"}`, country: "KZ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t, nil)
+			scriptIvan(app.api)
+
+			app.create(tt.body)
+
+			for _, p := range []mockserver.Provider{mockserver.Agify, mockserver.Genderize} {
+				requests := app.api.Requests(p)
+				if len(requests) != 1 || requests[0].Query.Get("country_id") != tt.country {
+					t.Errorf("%s requests = %+v, want one with country_id=%s", p, requests, tt.country)
+				}
+			}
+			if got := app.api.Requests(mockserver.Nationalize)[0].Query.Get("country_id"); got != "" {
+				t.Errorf("nationalize got country_id=%s, want none", got)
+			}
+		})
+	}
+}
+
+func TestCreateRejectsInvalidCountryHint(t *testing.T) {
+	app := newTestApp(t, nil)
+
+	resp := app.do(http.MethodPost, "/api/v1/persons/", `{"name":"Ivan","surname":"Petrov","country_hint":"Russia"}`)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if n := len(app.api.Requests(mockserver.Agify)); n != 0 {
+		t.Errorf("agify got %d requests for invalid person, want 0", n)
+	}
+}
+
+func TestCreateWithFailingProviders(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		provider mockserver.Provider
+		response mockserver.Response
+	}{
+		{name: "unknown name", provider: mockserver.Agify, response: mockserver.Unknown(mockserver.Agify)},
+		{name: "malformed json", provider: mockserver.Agify, response: mockserver.Malformed()},
+		{name: "server error", provider: mockserver.Agify, response: mockserver.Status(http.StatusInternalServerError)},
+		{name: "not found", provider: mockserver.Agify, response: mockserver.Status(http.StatusNotFound)},
+		{
+			name:     "timeout",
+			env:      map[string]string{"ENRICHMENT_AGIFY_TIMEOUT": "50ms", "ENRICHMENT_RETRY_MAX": "0"},
+			provider: mockserver.Agify,
+			response: mockserver.Slow(mockserver.Age(42, 1200), time.Second),
+		},
+		{
+			name:     "rate limited",
+			env:      map[string]string{"ENRICHMENT_RETRY_MAX": "1"},
+			provider: mockserver.Agify,
+			response: mockserver.TooManyRequests("3600"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t, tt.env)
+			scriptIvan(app.api)
+			app.api.Handle(tt.provider, "Ivan", tt.response)
+
+			person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+
+			if person.Age != nil {
+				t.Errorf("age = %d, want null", *person.Age)
+			}
+			if person.Gender == nil || person.Nationality == nil {
+				t.Errorf("gender = %v, nationality = %v, want other attributes enriched", person.Gender, person.Nationality)
+			}
+		})
+	}
+}
+
+func TestCreateRetriesRateLimitedProvider(t *testing.T) {
+	app := newTestApp(t, nil)
+	scriptIvan(app.api)
+	app.api.Handle(mockserver.Genderize, "Ivan",
+		mockserver.TooManyRequests("0"),
+		mockserver.Gender("male", 0.99, 1500))
+
+	person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+
+	if person.Gender == nil || *person.Gender != "male" {
+		t.Errorf("gender = %v, want male after retry", person.Gender)
+	}
+	if n := len(app.api.Requests(mockserver.Genderize)); n != 2 {
+		t.Errorf("genderize got %d requests, want 2", n)
+	}
+}
+
+func TestCreateRejectsLowConfidenceGender(t *testing.T) {
+	app := newTestApp(t, nil)
+	scriptIvan(app.api)
+	app.api.Handle(mockserver.Genderize, "Ivan", mockserver.Gender("female", 0.55, 40))
+
+	person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+
+	if person.Gender != nil {
+		t.Errorf("gender = %s, want null", *person.Gender)
+	}
+	gender := person.Provenance["gender"]
+	if gender == nil || gender.RejectedValue == nil || *gender.RejectedValue != "female" ||
+		gender.RejectedReason == nil || *gender.RejectedReason != "low_probability" {
+		t.Errorf("gender provenance = %+v, want rejected female for low probability", gender)
+	}
+}
+
+func TestBreakerStopsCallingFailingProvider(t *testing.T) {
+	app := newTestApp(t, map[string]string{
+		"ENRICHMENT_RETRY_MAX":            "0",
+		"ENRICHMENT_BREAKER_FAILURES":     "2",
+		"ENRICHMENT_BREAKER_OPEN_TIMEOUT": "1m",
+	})
+	app.api.Handle(mockserver.Agify, "", mockserver.Status(http.StatusServiceUnavailable))
+
+	for _, surname := range []string{"Petrov", "Sidorov", "Ivanov"} {
+		app.create(`{"name":"Ivan","surname":"` + surname + `"}`)
+	}
+
+	if n := len(app.api.Requests(mockserver.Agify)); n != 2 {
+		t.Errorf("agify got %d requests, want 2 before the breaker opened", n)
+	}
+
+	snapshots := decode[[]breaker.Snapshot](t, app.do(http.MethodGet, "/api/v1/admin/enrichment/breakers", ""))
+	for _, s := range *snapshots {
+		if s.Name == "agify" && s.State != breaker.StateOpen {
+			t.Errorf("agify breaker state = %s, want %s", s.State, breaker.StateOpen)
+		}
+	}
+}
+
+func TestCacheAvoidsRepeatedLookups(t *testing.T) {
+	app := newTestApp(t, map[string]string{"ENRICHMENT_CACHE_ENABLED": "true"})
+	scriptIvan(app.api)
+
+	app.create(`{"name":"Ivan","surname":"Petrov"}`)
+	person := app.create(`{"name":"ivan","surname":"Sidorov"}`)
+
+	if person.Age == nil || *person.Age != 42 {
+		t.Errorf("age = %v, want 42 from cache", person.Age)
+	}
+	for _, p := range []mockserver.Provider{mockserver.Agify, mockserver.Genderize, mockserver.Nationalize} {
+		if n := len(app.api.Requests(p)); n != 1 {
+			t.Errorf("%s got %d requests, want 1", p, n)
+		}
+	}
+
+	stats := decode[cache.Stats](t, app.do(http.MethodGet, "/api/v1/admin/enrichment/cache", ""))
+	if stats.MemoryHits != 3 {
+		t.Errorf("memory hits = %d, want 3", stats.MemoryHits)
+	}
+}
+
+func TestBatchCoalescesConcurrentCreates(t *testing.T) {
+	app := newTestApp(t, map[string]string{
+		"ENRICHMENT_BATCH_ENABLED": "true",
+		"ENRICHMENT_BATCH_WINDOW":  "200ms",
+	})
+	names := []string{"Ivan", "Petr", "Oleg"}
+	for _, name := range names {
+		app.api.Handle(mockserver.Agify, name, mockserver.Age(30, 100))
+		app.api.Handle(mockserver.Nationalize, name, mockserver.Nationality(100,
+			mockserver.Country{CountryID: "RU", Probability: 0.9}))
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			resp := app.do(http.MethodPost, "/api/v1/persons/", `{"name":"`+name+`","surname":"Petrov"}`)
+			resp.Body.Close()
+		}(name)
+	}
+	wg.Wait()
+
+	for _, p := range []mockserver.Provider{mockserver.Agify, mockserver.Nationalize} {
+		requests := app.api.Requests(p)
+		if len(requests) != 1 || len(requests[0].Query["name[]"]) != len(names) {
+			t.Errorf("%s requests = %+v, want one batch of %d names", p, requests, len(names))
+		}
+	}
+}
+
+func TestAsyncCreateEnrichesInBackground(t *testing.T) {
+	app := newTestApp(t, map[string]string{
+		"ENRICHMENT_ASYNC_ENABLED":       "true",
+		"ENRICHMENT_ASYNC_POLL_INTERVAL": "10ms",
+	})
+	scriptIvan(app.api)
+
+	resp := app.do(http.MethodPost, "/api/v1/persons/", `{"name":"Ivan","surname":"Petrov","country_hint":"BY"}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	id := decode[models.Identifier](t, resp).ID
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		person := app.get(id)
+		if person.EnrichmentStatus == models.EnrichmentStatusDone {
+			if person.Age == nil || *person.Age != 42 {
+				t.Errorf("age = %v, want 42", person.Age)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("enrichment status = %q, want %q", person.EnrichmentStatus, models.EnrichmentStatusDone)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := app.api.Requests(mockserver.Agify)[0].Query.Get("country_id"); got != "BY" {
+		t.Errorf("agify country_id = %q, want BY from the client hint", got)
+	}
+}
+
+func TestAsyncEnrichmentKeepsManualChanges(t *testing.T) {
+	app := newTestApp(t, map[string]string{
+		"ENRICHMENT_ASYNC_ENABLED":       "true",
+		"ENRICHMENT_ASYNC_POLL_INTERVAL": "10ms",
+	})
+	scriptIvan(app.api)
+	app.api.Handle(mockserver.Agify, "Ivan", mockserver.Slow(mockserver.Age(42, 1200), 300*time.Millisecond))
+
+	resp := app.do(http.MethodPost, "/api/v1/persons/", `{"name":"Ivan","surname":"Petrov"}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	id := decode[models.Identifier](t, resp).ID
+
+	// возраст задается вручную, пока источник отвечает
+	for len(app.api.Requests(mockserver.Agify)) == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	app.do(http.MethodPatch, "/api/v1/persons/"+itoa(id), `{"age":30}`).Body.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	person := app.get(id)
+	for person.EnrichmentStatus == models.EnrichmentStatusPending {
+		if time.Now().After(deadline) {
+			t.Fatalf("enrichment status = %q, want it completed", person.EnrichmentStatus)
+		}
+		time.Sleep(10 * time.Millisecond)
+		person = app.get(id)
+	}
+
+	if person.Age == nil || *person.Age != 30 {
+		t.Errorf("age = %v, want 30 set manually", person.Age)
+	}
+	if person.Gender == nil || *person.Gender != "male" {
+		t.Errorf("gender = %v, want male from the provider", person.Gender)
+	}
+	if p := person.Provenance["age"]; p == nil || p.Source != models.SourceManual {
+		t.Errorf("age provenance = %+v, want manual", p)
+	}
+}
+
+func TestReenrichManyQueuesPersons(t *testing.T) {
+	app := newTestApp(t, map[string]string{"ENRICHMENT_ASYNC_POLL_INTERVAL": "10ms"})
+	scriptIvan(app.api)
+	app.api.Handle(mockserver.Agify, "Ivan", mockserver.Age(42, 1200), mockserver.Age(50, 1300))
+
+	person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+	app.do(http.MethodPatch, "/api/v1/persons/"+itoa(person.ID), `{"gender":"female"}`).Body.Close()
+
+	// ждет, пока фоновое обогащение изменит профиль
+	await := func(done func(p *models.FullPerson) bool) *models.FullPerson {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			p := app.get(person.ID)
+			if done(p) {
+				return p
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("person = %+v, enrichment did not finish", p)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	enqueue := func(query string) {
+		t.Helper()
+		resp := app.do(http.MethodPost, "/api/v1/persons/enrich"+query, "")
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("enrich%s: status %d, want %d", query, resp.StatusCode, http.StatusAccepted)
+		}
+		if ids := decode[[]int64](t, resp); len(*ids) != 1 || (*ids)[0] != person.ID {
+			t.Errorf("enrich%s queued %v, want [%d]", query, *ids, person.ID)
+		}
+	}
+
+	enqueue("")
+	enriched := await(func(p *models.FullPerson) bool { return p.Age != nil && *p.Age == 50 })
+	if enriched.Gender == nil || *enriched.Gender != "female" {
+		t.Errorf("gender = %v, want female set manually", enriched.Gender)
+	}
+
+	enqueue("?overwrite_manual=true")
+	await(func(p *models.FullPerson) bool { return p.Gender != nil && *p.Gender == "male" })
+}
+
+func TestReenrichRefreshesCandidatesOfUnchangedNationality(t *testing.T) {
+	app := newTestApp(t, nil)
+	scriptIvan(app.api)
+	app.api.Handle(mockserver.Nationalize, "Ivan",
+		mockserver.Nationality(3000, mockserver.Country{CountryID: "RU", Probability: 0.61}, mockserver.Country{CountryID: "UA", Probability: 0.2}),
+		mockserver.Nationality(3500, mockserver.Country{CountryID: "RU", Probability: 0.7}, mockserver.Country{CountryID: "BY", Probability: 0.1}))
+
+	person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+
+	report := decode[models.EnrichmentReport](t, app.do(http.MethodPost, "/api/v1/persons/"+itoa(person.ID)+"/enrich", ""))
+	if len(report.Changes) != 0 {
+		t.Errorf("changes = %+v, want none", report.Changes)
+	}
+
+	candidates := decode[[]models.NationalityCandidate](t, app.do(http.MethodGet, "/api/v1/persons/"+itoa(person.ID)+"/nationalities", ""))
+	want := []models.NationalityCandidate{{CountryID: "RU", Probability: 0.7}, {CountryID: "BY", Probability: 0.1}}
+	if !slices.Equal(*candidates, want) {
+		t.Errorf("candidates = %v, want %v", *candidates, want)
+	}
+
+	nationality := app.get(person.ID).Provenance["nationality"]
+	if nationality == nil || nationality.SampleCount == nil || *nationality.SampleCount != 3500 {
+		t.Errorf("nationality provenance = %+v, want refreshed sample count 3500", nationality)
+	}
+}
+
+func TestQuotaBudgetPostponesEnrichment(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    map[string]string
+		script func(api *mockserver.Server)
+	}{
+		{
+			name: "daily budget",
+			env:  map[string]string{"ENRICHMENT_QUOTA_DAILY_BUDGET": "1"},
+		},
+		{
+			name: "remaining header",
+			script: func(api *mockserver.Server) {
+				api.Handle(mockserver.Agify, "Ivan",
+					mockserver.WithHeader(mockserver.Age(42, 1200), "X-Rate-Limit-Remaining", "0"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t, tt.env)
+			scriptIvan(app.api)
+			if tt.script != nil {
+				tt.script(app.api)
+			}
+
+			first := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+			if first.Age == nil {
+				t.Fatalf("first person not enriched before the quota ran out")
+			}
+
+			resp := app.do(http.MethodPost, "/api/v1/persons/", `{"name":"Ivan","surname":"Sidorov"}`)
+			if resp.StatusCode != http.StatusAccepted {
+				t.Fatalf("status %d, want %d when quota is exhausted", resp.StatusCode, http.StatusAccepted)
+			}
+			second := app.get(decode[models.Identifier](t, resp).ID)
+
+			if second.EnrichmentStatus != models.EnrichmentStatusPending || second.Age != nil {
+				t.Errorf("person = %+v, want pending without enrichment", second)
+			}
+			if n := len(app.api.Requests(mockserver.Agify)); n != 1 {
+				t.Errorf("agify got %d requests, want 1", n)
+			}
+
+			usages := decode[[]quota.Usage](t, app.do(http.MethodGet, "/api/v1/admin/enrichment/quota", ""))
+			for _, u := range *usages {
+				if u.Provider == "agify" && (u.Used != 1 || !u.Exhausted) {
+					t.Errorf("agify quota = %+v, want 1 used and exhausted", u)
+				}
+			}
+
+			stored, _ := app.store.QuotaUsage(context.Background(), time.Now().UTC().Truncate(24*time.Hour))
+			if len(stored) != 3 {
+				t.Errorf("got %d persisted quota counters, want 3", len(stored))
+			}
+		})
+	}
+}
+
+func TestCyrillicNameEnrichedByTransliteration(t *testing.T) {
+	app := newTestApp(t, nil)
+	app.api.Handle(mockserver.Agify, "Dmitrii", mockserver.Age(35, 800))
+	app.api.Handle(mockserver.Genderize, "Dmitrii", mockserver.Gender("male", 0.99, 900))
+
+	person := app.create(`{"name":"Дмитрий","surname":"Щукин","patronymic":"Юрьевич"}`)
+
+	if person.Age == nil || *person.Age != 35 || person.Gender == nil || *person.Gender != "male" {
+		t.Errorf("age, gender = %v, %v, want 35, male", person.Age, person.Gender)
+	}
+}
+
+func TestCyrillicNameStoredAndFoundInLatin(t *testing.T) {
+	app := newPostgresTestApp(t, nil)
+	person := app.create(`{"name":"Дмитрий","surname":"Щукин","patronymic":"Юрьевич"}`)
+
+	if person.NameLatin != "Dmitrii" || person.SurnameLatin != "Shchukin" ||
+		person.PatronymicLatin == nil || *person.PatronymicLatin != "Iurevich" {
+		t.Errorf("latin name = %q %q %v, want Dmitrii Shchukin Iurevich", person.NameLatin, person.SurnameLatin, person.PatronymicLatin)
+	}
+
+	latin := app.create(`{"name":"Ivan","surname":"Ivanov"}`)
+
+	for query, want := range map[string]int64{
+		"shchukin":    person.ID,
+		"Щукин":       person.ID,
+		"Иванов":      latin.ID,
+		"dmitrii iur": person.ID,
+	} {
+		found := *decode[[]*models.ScoredPerson](t, app.do(http.MethodGet, "/api/v1/persons/search?q="+url.QueryEscape(query), ""))
+		if len(found) == 0 || found[0].ID != want {
+			t.Errorf("search %q found %v, want person %d first", query, found, want)
+		}
+	}
+}
+
+func TestCacheExpiresEmptyResultsEarly(t *testing.T) {
+	app := newTestApp(t, map[string]string{
+		"ENRICHMENT_CACHE_ENABLED":      "true",
+		"ENRICHMENT_CACHE_NEGATIVE_TTL": "50ms",
+	})
+	scriptIvan(app.api)
+	app.api.Handle(mockserver.Agify, "Ivan", mockserver.Unknown(mockserver.Agify), mockserver.Age(42, 1200))
+
+	first := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+	time.Sleep(100 * time.Millisecond)
+	second := app.create(`{"name":"Ivan","surname":"Sidorov"}`)
+
+	if first.Age != nil {
+		t.Errorf("first age = %d, want null", *first.Age)
+	}
+	if second.Age == nil || *second.Age != 42 {
+		t.Errorf("second age = %v, want 42 after the empty result expired", second.Age)
+	}
+	if n := len(app.api.Requests(mockserver.Agify)); n != 2 {
+		t.Errorf("agify got %d requests, want 2", n)
+	}
+	if n := len(app.api.Requests(mockserver.Genderize)); n != 1 {
+		t.Errorf("genderize got %d requests, want 1 from cache", n)
+	}
+}
@@ -8,13 +8,9 @@ import (
 
 	_ "github.com/Gustcat/people-info-service/docs"
 	"github.com/Gustcat/people-info-service/internal/config"
-	"github.com/Gustcat/people-info-service/internal/http-server/handlers/persons"
 	"github.com/Gustcat/people-info-service/internal/logger"
 	"github.com/Gustcat/people-info-service/internal/repository/postgres"
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/joho/godotenv"
-	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 const (
@@ -55,20 +51,11 @@ func main() {
 	}
 	defer repo.Close()
 
-	log.Debug("Try to setup router")
-	router := chi.NewRouter()
-
-	router.Use(middleware.Recoverer)
-
-	router.Get("/swagger/*", httpSwagger.WrapHandler)
-
-	router.Route("/api/v1/persons", func(r chi.Router) {
-		r.Post("/", persons.Create(ctx, log, repo))
-		r.Get("/", persons.List(ctx, log, repo))
-		r.Get("/{id}", persons.GetByID(ctx, log, repo))
-		r.Patch("/{id}", persons.Update(ctx, log, repo))
-		r.Delete("/{id}", persons.Delete(ctx, log, repo))
-	})
+	router, err := newRouter(ctx, log, conf, repo)
+	if err != nil {
+		log.Error("doesn't setup router", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
 	srv := &http.Server{
 		Addr:         conf.HTTPServer.Address,
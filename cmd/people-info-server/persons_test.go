@@ -0,0 +1,581 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/enrichment/mockserver"
+	"github.com/Gustcat/people-info-service/internal/lib/audit"
+	"github.com/Gustcat/people-info-service/internal/lib/etag"
+	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+// doWithHeader выполняет запрос с дополнительным заголовком
+func (a *testApp) doWithHeader(method, path, body, key, value string) *http.Response {
+	a.t.Helper()
+
+	req, err := http.NewRequest(method, a.srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		a.t.Fatalf("request: %v", err)
+	}
+	req.Header.Set(key, value)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		a.t.Fatalf("%s %s: %v", method, path, err)
+	}
+
+	return resp
+}
+
+// history возвращает страницу журнала изменений профиля вместе с пагинацией
+func (a *testApp) history(id int64, query string) ([]*models.HistoryEntry, *response.Pagination) {
+	a.t.Helper()
+
+	resp := a.do(http.MethodGet, "/api/v1/persons/"+itoa(id)+"/history"+query, "")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		a.t.Fatalf("history: status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var body response.Response[[]*models.HistoryEntry]
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		a.t.Fatalf("decoding response: %v", err)
+	}
+
+	return *body.Data, body.Pagination
+}
+
+func TestHistoryRecordsPersonChanges(t *testing.T) {
+	app := newTestApp(t, nil)
+	scriptIvan(app.api)
+
+	person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+
+	app.doWithHeader(http.MethodPatch, "/api/v1/persons/"+itoa(person.ID), `{"nationality":"KZ"}`,
+		audit.HeaderActor, "compliance@example.com").Body.Close()
+
+	if resp := app.do(http.MethodDelete, "/api/v1/persons/"+itoa(person.ID), ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete: status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	entries, pagination := app.history(person.ID, "?limit=2")
+	if pagination.Total == nil || *pagination.Total != 3 || pagination.Next == nil {
+		t.Fatalf("pagination = %+v, want 3 entries with a next page", pagination)
+	}
+	if len(entries) != 2 || entries[0].Action != models.HistoryActionCreate || entries[1].Action != models.HistoryActionUpdate {
+		t.Fatalf("entries = %+v, want create and update", entries)
+	}
+
+	update := entries[1]
+	want := models.HistoryChange{Field: "nationality", Old: "RU", New: "KZ"}
+	if len(update.Changes) != 1 || update.Changes[0] != want {
+		t.Errorf("update changes = %+v, want %+v", update.Changes, want)
+	}
+	if update.Actor == nil || *update.Actor != "compliance@example.com" || update.Source != models.HistorySourceAPI {
+		t.Errorf("update actor = %v, source = %q, want compliance@example.com via api", update.Actor, update.Source)
+	}
+
+	entries, _ = app.history(person.ID, "?limit=2&offset=2")
+	if len(entries) != 1 || entries[0].Action != models.HistoryActionDelete {
+		t.Errorf("entries = %+v, want delete", entries)
+	}
+
+	if resp := app.do(http.MethodGet, "/api/v1/persons/100/history", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown person history: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestHistoryMarksEnrichmentSource(t *testing.T) {
+	app := newTestApp(t, nil)
+	scriptIvan(app.api)
+
+	person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+	app.api.Handle(mockserver.Agify, "Ivan", mockserver.Age(43, 1300))
+
+	if resp := app.do(http.MethodPost, "/api/v1/persons/"+itoa(person.ID)+"/enrich", ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("enrich: status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	entries, _ := app.history(person.ID, "")
+	if len(entries) != 2 {
+		t.Fatalf("got %d history entries, want 2", len(entries))
+	}
+	if e := entries[1]; e.Source != models.HistorySourceEnrichment || e.Changes[0].Field != "age" {
+		t.Errorf("entry = %+v, want age change from enrichment", e)
+	}
+}
+
+func TestDeleteHidesPersonUntilRestore(t *testing.T) {
+	app := newTestApp(t, nil)
+	scriptIvan(app.api)
+
+	person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+	path := "/api/v1/persons/" + itoa(person.ID)
+
+	if resp := app.do(http.MethodDelete, path, ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete: status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp := app.do(http.MethodDelete, path, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("repeated delete: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if resp := app.do(http.MethodGet, path, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("get deleted: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if resp := app.do(http.MethodPatch, path, `{"age":50}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("patch deleted: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	if listed := decode[[]*models.FullPerson](t, app.do(http.MethodGet, "/api/v1/persons/", "")); len(*listed) != 0 {
+		t.Errorf("list returned %d persons, want deleted person hidden", len(*listed))
+	}
+	listed := decode[[]*models.FullPerson](t, app.do(http.MethodGet, "/api/v1/persons/?include_deleted=true", ""))
+	if len(*listed) != 1 || (*listed)[0].DeletedAt == nil {
+		t.Errorf("list with include_deleted = %+v, want deleted person with deleted_at", *listed)
+	}
+
+	resp := app.do(http.MethodPost, path+"/restore", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("restore: status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if restored := decode[models.FullPerson](t, resp); restored.DeletedAt != nil || restored.Age == nil {
+		t.Errorf("restored person = %+v, want enriched person without deleted_at", restored)
+	}
+	if resp := app.do(http.MethodPost, path+"/restore", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("restore active person: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	entries, _ := app.history(person.ID, "")
+	if len(entries) != 3 || entries[1].Action != models.HistoryActionDelete || entries[2].Action != models.HistoryActionRestore {
+		t.Errorf("history = %+v, want create, delete and restore", entries)
+	}
+}
+
+func TestRestoreRejectsDuplicateName(t *testing.T) {
+	app := newTestApp(t, nil)
+	scriptIvan(app.api)
+
+	deleted := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+	app.do(http.MethodDelete, "/api/v1/persons/"+itoa(deleted.ID), "").Body.Close()
+	app.create(`{"name":"Ivan","surname":"Petrov"}`)
+
+	if resp := app.do(http.MethodPost, "/api/v1/persons/"+itoa(deleted.ID)+"/restore", ""); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("restore: status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestPurgeRemovesExpiredDeletedPersons(t *testing.T) {
+	app := newTestApp(t, map[string]string{
+		"RETENTION_PERIOD":         "1ms",
+		"RETENTION_PURGE_INTERVAL": "10ms",
+	})
+	scriptIvan(app.api)
+
+	person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+	app.do(http.MethodDelete, "/api/v1/persons/"+itoa(person.ID), "").Body.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		listed := decode[[]*models.FullPerson](t, app.do(http.MethodGet, "/api/v1/persons/?include_deleted=true", ""))
+		if len(*listed) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("deleted person was not purged")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if resp := app.do(http.MethodPost, "/api/v1/persons/"+itoa(person.ID)+"/restore", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("restore purged person: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	entries, _ := app.history(person.ID, "")
+	last := entries[len(entries)-1]
+	if last.Action != models.HistoryActionPurge || last.Source != models.HistorySourceRetention {
+		t.Errorf("last history entry = %+v, want purge by retention", last)
+	}
+}
+
+func TestIfMatchPreventsLostUpdates(t *testing.T) {
+	app := newTestApp(t, nil)
+	scriptIvan(app.api)
+
+	person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+	path := "/api/v1/persons/" + itoa(person.ID)
+
+	resp := app.do(http.MethodGet, path, "")
+	resp.Body.Close()
+	tag := resp.Header.Get(etag.HeaderETag)
+	if tag != `"1"` {
+		t.Fatalf("ETag = %q, want %q", tag, `"1"`)
+	}
+
+	resp = app.doWithHeader(http.MethodPatch, path, `{"age":50}`, etag.HeaderIfMatch, tag)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(etag.HeaderETag) != `"2"` {
+		t.Fatalf("patch: status %d, ETag %q, want 200 with %q", resp.StatusCode, resp.Header.Get(etag.HeaderETag), `"2"`)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		ifMatch string
+	}{
+		{name: "stale patch", method: http.MethodPatch, ifMatch: tag},
+		{name: "stale delete", method: http.MethodDelete, ifMatch: tag},
+		{name: "weak etag", method: http.MethodPatch, ifMatch: `W/"2"`},
+		{name: "unknown etag", method: http.MethodDelete, ifMatch: `"abc"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := app.doWithHeader(tt.method, path, `{"age":60}`, etag.HeaderIfMatch, tt.ifMatch)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusPreconditionFailed {
+				t.Errorf("status %d, want %d", resp.StatusCode, http.StatusPreconditionFailed)
+			}
+		})
+	}
+
+	if got := app.get(person.ID); got.Age == nil || *got.Age != 50 || got.DeletedAt != nil {
+		t.Errorf("person = %+v, want age 50 kept after rejected requests", got)
+	}
+
+	resp = app.doWithHeader(http.MethodDelete, path, "", etag.HeaderIfMatch, `"2"`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("delete: status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestStrictModeRequiresIfMatch(t *testing.T) {
+	app := newTestApp(t, map[string]string{"HTTP_REQUIRE_IF_MATCH": "true"})
+	scriptIvan(app.api)
+
+	person := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+	path := "/api/v1/persons/" + itoa(person.ID)
+
+	for _, method := range []string{http.MethodPatch, http.MethodDelete} {
+		resp := app.do(method, path, `{"age":50}`)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusPreconditionRequired {
+			t.Errorf("%s without If-Match: status %d, want %d", method, resp.StatusCode, http.StatusPreconditionRequired)
+		}
+	}
+
+	resp := app.doWithHeader(http.MethodPatch, path, `{"age":50}`, etag.HeaderIfMatch, "*")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("patch with If-Match *: status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestUpdatedAfterReturnsOnlyChangedPersons(t *testing.T) {
+	app := newPostgresTestApp(t, nil)
+	scriptIvan(app.api)
+
+	changed := app.create(`{"name":"Ivan","surname":"Petrov"}`)
+	unchanged := app.create(`{"name":"Ivan","surname":"Sidorov"}`)
+	if unchanged.CreatedAt.IsZero() || unchanged.UpdatedAt.Before(unchanged.CreatedAt) {
+		t.Fatalf("timestamps = %v / %v, want created_at and updated_at set", unchanged.CreatedAt, unchanged.UpdatedAt)
+	}
+	since := url.QueryEscape(unchanged.UpdatedAt.Format(time.RFC3339Nano))
+
+	app.do(http.MethodPatch, "/api/v1/persons/"+itoa(changed.ID), `{"age":50}`).Body.Close()
+
+	listed := decode[[]*models.FullPerson](t, app.do(http.MethodGet, "/api/v1/persons/?updated_after="+since, ""))
+	if len(*listed) != 1 || (*listed)[0].ID != changed.ID {
+		t.Errorf("updated_after returned %+v, want only person %d", *listed, changed.ID)
+	}
+	if !(*listed)[0].UpdatedAt.After((*listed)[0].CreatedAt) {
+		t.Errorf("updated_at = %v, want after created_at %v", (*listed)[0].UpdatedAt, (*listed)[0].CreatedAt)
+	}
+
+	listed = decode[[]*models.FullPerson](t, app.do(http.MethodGet, "/api/v1/persons/?created_after="+since, ""))
+	if len(*listed) != 0 {
+		t.Errorf("created_after returned %d persons, want none", len(*listed))
+	}
+}
+
+func TestListSortsWithTieBreakOnID(t *testing.T) {
+	app := newPostgresTestApp(t, nil)
+	scriptIvan(app.api)
+	app.api.Handle(mockserver.Agify, "Anna", mockserver.Age(30, 900))
+
+	ivanP := app.create(`{"name":"Ivan","surname":"Petrov"}`).ID
+	oleg := app.create(`{"name":"Oleg","surname":"Ivanov"}`).ID
+	ivanS := app.create(`{"name":"Ivan","surname":"Sidorov"}`).ID
+	anna := app.create(`{"name":"Anna","surname":"Petrova"}`).ID
+
+	tests := []struct {
+		sort string
+		want []int64
+	}{
+		{sort: "-age,surname", want: []int64{ivanP, ivanS, anna, oleg}},
+		{sort: "age", want: []int64{anna, ivanP, ivanS, oleg}},
+		{sort: "-surname", want: []int64{ivanS, anna, ivanP, oleg}},
+		{sort: "-id", want: []int64{anna, ivanS, oleg, ivanP}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			listed := decode[[]*models.FullPerson](t, app.do(http.MethodGet, "/api/v1/persons/?limit=10&sort="+tt.sort, ""))
+			got := make([]int64, 0, len(*listed))
+			for _, p := range *listed {
+				got = append(got, p.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	resp := app.do(http.MethodGet, "/api/v1/persons/?limit=2&sort=-age,surname", "")
+	defer resp.Body.Close()
+	var page response.Response[[]*models.FullPerson]
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if page.Pagination.Next == nil || !strings.Contains(*page.Pagination.Next, "sort=-age%2Csurname") {
+		t.Errorf("next link = %v, want it to keep the sort order", page.Pagination.Next)
+	}
+}
+
+func (a *testApp) page(path string) response.Response[[]*models.FullPerson] {
+	a.t.Helper()
+
+	resp := a.do(http.MethodGet, path, "")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		a.t.Fatalf("GET %s: status %d, want %d", path, resp.StatusCode, http.StatusOK)
+	}
+
+	var page response.Response[[]*models.FullPerson]
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		a.t.Fatalf("decoding response: %v", err)
+	}
+
+	return page
+}
+
+func TestCursorWalksPagesBothWays(t *testing.T) {
+	app := newPostgresTestApp(t, nil)
+	scriptIvan(app.api)
+	app.api.Handle(mockserver.Agify, "Anna", mockserver.Age(30, 900))
+
+	ivanP := app.create(`{"name":"Ivan","surname":"Petrov"}`).ID
+	oleg := app.create(`{"name":"Oleg","surname":"Ivanov"}`).ID
+	ivanS := app.create(`{"name":"Ivan","surname":"Sidorov"}`).ID
+	anna := app.create(`{"name":"Anna","surname":"Petrova"}`).ID
+	want := []int64{ivanP, ivanS, anna, oleg}
+
+	const base = "/api/v1/persons/?limit=1&count=false&sort=-age,surname"
+	var forward []int64
+	var last string
+	for path := base; ; {
+		page := app.page(path)
+		if page.Pagination.Total != nil {
+			t.Errorf("total = %d with count=false, want it omitted", *page.Pagination.Total)
+		}
+		for _, p := range *page.Data {
+			forward = append(forward, p.ID)
+		}
+		if page.Pagination.NextCursor == nil {
+			last = path
+			break
+		}
+		path = base + "&cursor=" + url.QueryEscape(*page.Pagination.NextCursor)
+	}
+	if !slices.Equal(forward, want) {
+		t.Fatalf("forward order = %v, want %v", forward, want)
+	}
+
+	var backward []int64
+	for path := last; ; {
+		page := app.page(path)
+		for _, p := range *page.Data {
+			backward = append([]int64{p.ID}, backward...)
+		}
+		if page.Pagination.PrevCursor == nil {
+			break
+		}
+		path = base + "&cursor=" + url.QueryEscape(*page.Pagination.PrevCursor)
+	}
+	if !slices.Equal(backward, want) {
+		t.Errorf("backward order = %v, want %v", backward, want)
+	}
+
+	page := app.page("/api/v1/persons/?limit=3&sort=-age,surname")
+	if page.Pagination.Total == nil || *page.Pagination.Total != 4 {
+		t.Errorf("total = %v, want 4", page.Pagination.Total)
+	}
+	if next := app.page("/api/v1/persons/?limit=3&sort=-age,surname&cursor=" + url.QueryEscape(*page.Pagination.NextCursor)); len(*next.Data) != 1 || (*next.Data)[0].ID != oleg {
+		t.Errorf("page after cursor = %v, want only %d", *next.Data, oleg)
+	}
+}
+
+func TestListFiltersWithOperators(t *testing.T) {
+	app := newPostgresTestApp(t, nil)
+	scriptIvan(app.api)
+	app.api.Handle(mockserver.Agify, "Anna", mockserver.Age(30, 900))
+	app.api.Handle(mockserver.Nationalize, "Taras", mockserver.Nationality(700,
+		mockserver.Country{CountryID: "UA", Probability: 0.7}))
+
+	ivanP := app.create(`{"name":"Ivan","surname":"Petrov"}`).ID
+	oleg := app.create(`{"name":"Oleg","surname":"Ivanov"}`).ID
+	ivanS := app.create(`{"name":"Ivan","surname":"Sidorov"}`).ID
+	anna := app.create(`{"name":"Anna","surname":"Petrova"}`).ID
+	taras := app.create(`{"name":"Taras","surname":"Ivanenko"}`).ID
+
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{query: "nationality=RU,UA", want: []int64{ivanP, ivanS, taras}},
+		{query: "nationality=!RU", want: []int64{oleg, anna, taras}},
+		{query: "is_null=gender", want: []int64{oleg, anna, taras}},
+		{query: "not_null=age&gender=male", want: []int64{ivanP, ivanS}},
+		{query: "surname_prefix=Petr", want: []int64{ivanP, anna}},
+		{query: "surname_prefix=petr", want: []int64{}},
+		{query: "surname_prefix=petr&ignore_case=true", want: []int64{ivanP, anna}},
+		{query: "surname_prefix=!Iv", want: []int64{ivanP, ivanS, anna}},
+		{query: "name=ivan&ignore_case=true", want: []int64{ivanP, ivanS}},
+		{query: "name=!Ivan,Oleg", want: []int64{anna, taras}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			page := app.page("/api/v1/persons/?limit=10&" + tt.query)
+			got := make([]int64, 0, len(*page.Data))
+			for _, p := range *page.Data {
+				got = append(got, p.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListFiltersByExpression(t *testing.T) {
+	app := newPostgresTestApp(t, nil)
+	scriptIvan(app.api)
+	app.api.Handle(mockserver.Agify, "Anna", mockserver.Age(30, 900))
+	app.api.Handle(mockserver.Nationalize, "Taras", mockserver.Nationality(700,
+		mockserver.Country{CountryID: "UA", Probability: 0.7}))
+
+	ivanP := app.create(`{"name":"Ivan","surname":"Petrov"}`).ID
+	oleg := app.create(`{"name":"Oleg","surname":"Ivanov"}`).ID
+	ivanS := app.create(`{"name":"Ivan","surname":"Sidorov"}`).ID
+	anna := app.create(`{"name":"Anna","surname":"Petrova"}`).ID
+	taras := app.create(`{"name":"Taras","surname":"Ivanenko"}`).ID
+
+	tests := []struct {
+		q    string
+		want []int64
+	}{
+		{q: `(nationality = "RU" OR nationality = "BY") AND age >= 30 AND NOT gender IS NULL`, want: []int64{ivanP, ivanS}},
+		{q: `name IN ("Anna", "Oleg") or surname = "Ivanenko"`, want: []int64{oleg, anna, taras}},
+		{q: `age < 40 OR age IS NULL`, want: []int64{oleg, anna, taras}},
+		{q: `NOT (name = "Ivan" AND surname != "Petrov") AND id <= 4`, want: []int64{ivanP, oleg, anna}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			page := app.page("/api/v1/persons/?limit=10&q=" + url.QueryEscape(tt.q))
+			got := make([]int64, 0, len(*page.Data))
+			for _, p := range *page.Data {
+				got = append(got, p.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchRanksByNameSimilarity(t *testing.T) {
+	app := newPostgresTestApp(t, nil)
+	ivanP := app.create(`{"name":"Ivan","surname":"Petrov"}`).ID
+	oleg := app.create(`{"name":"Oleg","surname":"Ivanov"}`).ID
+	app.create(`{"name":"Ivan","surname":"Sidorov"}`)
+	anna := app.create(`{"name":"Anna","surname":"Petrova"}`).ID
+
+	search := func(query string) []*models.ScoredPerson {
+		t.Helper()
+		return *decode[[]*models.ScoredPerson](t, app.do(http.MethodGet, "/api/v1/persons/search?"+query, ""))
+	}
+
+	found := search("q=PETROV")
+	if len(found) != 2 || found[0].ID != ivanP || found[1].ID != anna {
+		t.Fatalf("found %v, want persons %d and %d", found, ivanP, anna)
+	}
+	if found[0].Score != 1 || found[1].Score >= found[0].Score {
+		t.Errorf("scores = %v, %v, want exact match first with score 1", found[0].Score, found[1].Score)
+	}
+
+	if found := search("q=ivanovv"); len(found) == 0 || found[0].ID != oleg {
+		t.Errorf("search with typo found %v, want person %d first", found, oleg)
+	}
+
+	if found := search("q=petrov&min_score=0.9"); len(found) != 1 || found[0].ID != ivanP {
+		t.Errorf("search with min_score found %v, want only person %d", found, ivanP)
+	}
+}
+
+func TestListRejectsInvalidParameters(t *testing.T) {
+	app := newTestApp(t, nil)
+	app.create(`{"name":"Ivan","surname":"Petrov"}`)
+	app.create(`{"name":"Oleg","surname":"Ivanov"}`)
+	cursor := url.QueryEscape(*app.page("/api/v1/persons/?limit=1").Pagination.NextCursor)
+
+	for _, query := range []string{
+		"updated_after=yesterday",
+		"sort=salary",
+		"sort=" + url.QueryEscape("age,-age"),
+		"sort=" + url.QueryEscape("age,"),
+		"cursor=x" + cursor,
+		"sort=age&cursor=" + cursor,
+		"offset=1&cursor=" + cursor,
+		"is_null=salary",
+		"nationality=RU,,UA",
+		"gender=foo",
+		"gender=" + url.QueryEscape("!male,foo"),
+	} {
+		resp := app.do(http.MethodGet, "/api/v1/persons/?"+query, "")
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", query, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+
+	for q, want := range map[string]string{
+		`age >= "30"`:                   `position 8 near "\"30\"": expected number`,
+		`salary = 1`:                    `position 1 near "salary": unknown field`,
+		`age = 1 AND`:                   `position 12: unexpected end of expression`,
+		`(age = 1 OR name = "Ivan" age`: `position 27 near "age": expected )`,
+		`gender = "x"`:                  `position 10 near "\"x\"": expected gender male or female`,
+		`gender IN ("male", "Female")`:  `position 20 near "\"Female\"": expected gender male or female`,
+	} {
+		resp := app.do(http.MethodGet, "/api/v1/persons/?q="+url.QueryEscape(q), "")
+		var body response.Response[response.Void]
+		err := json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if resp.StatusCode != http.StatusBadRequest || !strings.Contains(body.Error, want) {
+			t.Errorf("q=%s: status %d, error %q, want %d with %q", q, resp.StatusCode, body.Error, http.StatusBadRequest, want)
+		}
+	}
+
+	for _, query := range []string{"q=", "q=petrov&min_score=2"} {
+		resp := app.do(http.MethodGet, "/api/v1/persons/search?"+query, "")
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("search %s: status %d, want %d", query, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+}
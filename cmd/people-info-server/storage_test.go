@@ -0,0 +1,620 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/lib/audit"
+	"github.com/Gustcat/people-info-service/internal/lib/filter"
+	"github.com/Gustcat/people-info-service/internal/models"
+	"github.com/Gustcat/people-info-service/internal/repository"
+)
+
+// errNeedsPostgres запрос, который хранилище в памяти не выполняет
+var errNeedsPostgres = errors.New("memStorage: query requires Postgres, use newPostgresTestApp")
+
+// memStorage хранилище в памяти, заменяющее Postgres в интеграционных тестах
+type memStorage struct {
+	mu         sync.Mutex
+	nextID     int64
+	persons    map[int64]*models.FullPerson
+	provenance map[int64]map[string]*models.Provenance
+	candidates map[int64][]models.NationalityCandidate
+	jobs       map[int64]*memJob
+	names      map[string]memNameEnrichment
+	quota      map[string]*models.QuotaUsage
+	history    []*models.HistoryEntry
+}
+
+type memJob struct {
+	job      models.EnrichmentJob
+	runAfter time.Time
+	lastErr  string
+}
+
+type memNameEnrichment struct {
+	result    []byte
+	fetchedAt time.Time
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{
+		persons:    make(map[int64]*models.FullPerson),
+		provenance: make(map[int64]map[string]*models.Provenance),
+		candidates: make(map[int64][]models.NationalityCandidate),
+		jobs:       make(map[int64]*memJob),
+		names:      make(map[string]memNameEnrichment),
+		quota:      make(map[string]*models.QuotaUsage),
+	}
+}
+
+func (s *memStorage) Create(ctx context.Context, person *models.EnrichmentPerson) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.insert(ctx, person, models.EnrichmentStatusDone)
+}
+
+func (s *memStorage) CreatePending(ctx context.Context, person *models.Person) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := s.insert(ctx, &models.EnrichmentPerson{Person: *person}, models.EnrichmentStatusPending)
+	if err != nil {
+		return 0, err
+	}
+
+	s.nextID++
+	s.jobs[s.nextID] = &memJob{
+		job:      models.EnrichmentJob{ID: s.nextID, PersonID: id, CountryHint: person.CountryHint},
+		runAfter: time.Now(),
+	}
+
+	return id, nil
+}
+
+// insert сохраняет профиль; вызывается под s.mu
+func (s *memStorage) insert(ctx context.Context, person *models.EnrichmentPerson, status models.EnrichmentStatus) (int64, error) {
+	for _, p := range s.persons {
+		if p.DeletedAt == nil && p.Name == person.Name && p.Surname == person.Surname {
+			return 0, repository.ErrPersonExists
+		}
+	}
+
+	s.nextID++
+	stored := &models.FullPerson{
+		Identifier:       models.Identifier{ID: s.nextID},
+		EnrichmentPerson: *person,
+		EnrichmentStatus: status,
+		Version:          1,
+		CreatedAt:        time.Now(),
+	}
+	stored.UpdatedAt = stored.CreatedAt
+	stored.CountryHint = nil
+	stored.Provenance = nil
+	stored.NationalityCandidates = nil
+	s.persons[s.nextID] = stored
+	s.saveEnrichment(s.nextID, person.Provenance, person.NationalityCandidates)
+	s.record(ctx, models.HistoryActionCreate, nil, stored)
+
+	return s.nextID, nil
+}
+
+// saveEnrichment сохраняет происхождение атрибутов и кандидаты национальности; вызывается под s.mu
+func (s *memStorage) saveEnrichment(id int64, provenance map[string]*models.Provenance, candidates []models.NationalityCandidate) {
+	if s.provenance[id] == nil {
+		s.provenance[id] = make(map[string]*models.Provenance)
+	}
+	maps.Copy(s.provenance[id], provenance)
+	if candidates != nil {
+		s.candidates[id] = slices.Clone(candidates)
+	}
+}
+
+func (s *memStorage) GetByID(_ context.Context, id int64) (*models.FullPerson, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	person, ok := s.active(id)
+	if !ok {
+		return nil, repository.ErrPersonNotFound
+	}
+	p := *person
+
+	return &p, nil
+}
+
+// active возвращает неудаленный профиль; вызывается под s.mu
+func (s *memStorage) active(id int64) (*models.FullPerson, bool) {
+	person, ok := s.persons[id]
+	if !ok || person.DeletedAt != nil {
+		return nil, false
+	}
+
+	return person, true
+}
+
+// filtered возвращает идентификаторы профилей по порядку id; вызывается под s.mu.
+// Фильтры, сортировка и курсоры поддерживаются только в Postgres: их SQL проверяют тесты
+// на newPostgresTestApp, а хранилище в памяти его не повторяет.
+func (s *memStorage) filtered(f *filter.PersonFilter) ([]int64, error) {
+	plain := *f
+	plain.IncludeDeleted, plain.Limit, plain.Offset, plain.Count, plain.Cursor = nil, nil, nil, nil, nil
+	if plain != (filter.PersonFilter{}) {
+		return nil, errNeedsPostgres
+	}
+
+	var ids []int64
+	for _, id := range slices.Sorted(maps.Keys(s.persons)) {
+		if s.persons[id].DeletedAt != nil && (f.IncludeDeleted == nil || !*f.IncludeDeleted) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (s *memStorage) List(_ context.Context, f *filter.PersonFilter) ([]*models.FullPerson, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.filtered(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := uint64(len(ids))
+	ids = paged(ids, f)
+
+	persons := make([]*models.FullPerson, 0, len(ids))
+	for _, id := range ids {
+		p := *s.persons[id]
+		persons = append(persons, &p)
+	}
+
+	return persons, total, nil
+}
+
+func (s *memStorage) Search(context.Context, *filter.SearchFilter) ([]*models.ScoredPerson, uint64, error) {
+	return nil, 0, errNeedsPostgres
+}
+
+// paged возвращает страницу идентификаторов по f.Offset и f.Limit
+func paged(ids []int64, f *filter.PersonFilter) []int64 {
+	if f.Offset != nil {
+		ids = ids[min(int(*f.Offset), len(ids)):]
+	}
+	if f.Limit != nil {
+		ids = ids[:min(int(*f.Limit), len(ids))]
+	}
+
+	return ids
+}
+
+func (s *memStorage) EnqueueEnrichment(_ context.Context, f *filter.PersonFilter, overwriteManual bool) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.filtered(f)
+	if err != nil {
+		return nil, err
+	}
+	ids = paged(ids, f)
+
+	queued := make(map[int64]bool, len(s.jobs))
+	for _, j := range s.jobs {
+		queued[j.job.PersonID] = true
+	}
+	for _, id := range ids {
+		if queued[id] {
+			continue
+		}
+		s.nextID++
+		s.jobs[s.nextID] = &memJob{
+			job:      models.EnrichmentJob{ID: s.nextID, PersonID: id, OverwriteManual: overwriteManual},
+			runAfter: time.Now(),
+		}
+	}
+
+	return append(make([]int64, 0, len(ids)), ids...), nil
+}
+
+func (s *memStorage) LoadProvenance(_ context.Context, persons ...*models.FullPerson) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, person := range persons {
+		person.Provenance = maps.Clone(s.provenance[person.ID])
+		if person.Provenance == nil {
+			person.Provenance = make(map[string]*models.Provenance)
+		}
+	}
+
+	return nil
+}
+
+func (s *memStorage) Update(ctx context.Context, id int64, update *models.PersonUpdate, version *int64) (*models.FullPerson, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	person, ok := s.active(id)
+	if !ok {
+		return nil, repository.ErrPersonNotFound
+	}
+	if version != nil && *version != person.Version {
+		return nil, repository.ErrVersionMismatch
+	}
+	old := *person
+	applyUpdate(person, update)
+	touch(person)
+	s.record(ctx, models.HistoryActionUpdate, &old, person)
+
+	manual := &models.Provenance{Source: models.SourceManual}
+	provenance := make(map[string]*models.Provenance)
+	if update.Age != nil {
+		provenance["age"] = manual
+	}
+	if update.Gender != nil {
+		provenance["gender"] = manual
+	}
+	if update.Nationality != nil {
+		provenance["nationality"] = manual
+	}
+	s.saveEnrichment(id, provenance, nil)
+
+	p := *person
+
+	return &p, nil
+}
+
+func (s *memStorage) Delete(ctx context.Context, id int64, version *int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	person, ok := s.active(id)
+	if !ok {
+		return repository.ErrPersonNotFound
+	}
+	if version != nil && *version != person.Version {
+		return repository.ErrVersionMismatch
+	}
+	old := *person
+	now := time.Now()
+	person.DeletedAt = &now
+	touch(person)
+	s.record(ctx, models.HistoryActionDelete, &old, person)
+
+	return nil
+}
+
+func (s *memStorage) Restore(ctx context.Context, id int64) (*models.FullPerson, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	person, ok := s.persons[id]
+	if !ok || person.DeletedAt == nil {
+		return nil, repository.ErrPersonNotFound
+	}
+	for _, p := range s.persons {
+		if p.DeletedAt == nil && p.Name == person.Name && p.Surname == person.Surname {
+			return nil, repository.ErrPersonExists
+		}
+	}
+	old := *person
+	person.DeletedAt = nil
+	touch(person)
+	s.record(ctx, models.HistoryActionRestore, &old, person)
+
+	p := *person
+
+	return &p, nil
+}
+
+func (s *memStorage) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, person := range s.persons {
+		if person.DeletedAt == nil || !person.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+		s.record(ctx, models.HistoryActionPurge, person, nil)
+		delete(s.persons, id)
+		delete(s.provenance, id)
+		delete(s.candidates, id)
+		for jobID, j := range s.jobs {
+			if j.job.PersonID == id {
+				delete(s.jobs, jobID)
+			}
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (s *memStorage) NationalityCandidates(_ context.Context, id int64) ([]models.NationalityCandidate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.candidates[id]), nil
+}
+
+func (s *memStorage) ManualAttributes(_ context.Context, id int64) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.manual(id), nil
+}
+
+// manual атрибуты профиля, заданные вручную; вызывается под s.mu
+func (s *memStorage) manual(id int64) []string {
+	var attributes []string
+	for attr, p := range s.provenance[id] {
+		if p.Source == models.SourceManual {
+			attributes = append(attributes, attr)
+		}
+	}
+
+	return attributes
+}
+
+func (s *memStorage) ApplyEnrichment(ctx context.Context, id int64, update *models.EnrichmentUpdate) (*models.FullPerson, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	person, ok := s.active(id)
+	if !ok {
+		return nil, repository.ErrPersonNotFound
+	}
+	old := *person
+	applyUpdate(person, &update.PersonUpdate)
+	person.EnrichmentStatus = models.EnrichmentStatusDone
+	touch(person)
+	s.record(ctx, models.HistoryActionUpdate, &old, person)
+	s.saveEnrichment(id, update.Provenance, update.NationalityCandidates)
+
+	p := *person
+
+	return &p, nil
+}
+
+func (s *memStorage) ClaimEnrichmentJobs(_ context.Context, limit uint64, lease time.Duration) ([]*models.EnrichmentJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var jobs []*models.EnrichmentJob
+	for _, id := range slices.Sorted(maps.Keys(s.jobs)) {
+		j := s.jobs[id]
+		if uint64(len(jobs)) == limit || j.runAfter.After(now) {
+			continue
+		}
+		if _, ok := s.active(j.job.PersonID); !ok {
+			continue
+		}
+		j.job.Attempts++
+		j.runAfter = now.Add(lease)
+		job := j.job
+		jobs = append(jobs, &job)
+	}
+
+	return jobs, nil
+}
+
+func (s *memStorage) CompleteEnrichmentJob(
+	ctx context.Context,
+	job *models.EnrichmentJob,
+	person *models.EnrichmentPerson,
+	status models.EnrichmentStatus,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.active(job.PersonID)
+	if !ok {
+		return nil
+	}
+
+	var manual []string
+	if !job.OverwriteManual {
+		manual = s.manual(job.PersonID)
+	}
+	old := *stored
+	defer s.record(ctx, models.HistoryActionUpdate, &old, stored)
+	if person.Age != nil && !slices.Contains(manual, "age") {
+		stored.Age = person.Age
+	}
+	if person.Gender != nil && !slices.Contains(manual, "gender") {
+		stored.Gender = person.Gender
+	}
+	candidates := person.NationalityCandidates
+	if slices.Contains(manual, "nationality") {
+		candidates = nil
+	} else if person.Nationality != nil {
+		stored.Nationality = person.Nationality
+	}
+	provenance := maps.Clone(person.Provenance)
+	for _, attr := range manual {
+		delete(provenance, attr)
+	}
+	stored.EnrichmentStatus = status
+	touch(stored)
+	s.saveEnrichment(job.PersonID, provenance, candidates)
+	delete(s.jobs, job.ID)
+
+	return nil
+}
+
+func (s *memStorage) RetryEnrichmentJob(_ context.Context, job *models.EnrichmentJob, lastErr string, runAfter time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if j, ok := s.jobs[job.ID]; ok {
+		j.lastErr = lastErr
+		j.runAfter = runAfter
+	}
+
+	return nil
+}
+
+func (s *memStorage) GetNameEnrichment(_ context.Context, name, attribute, countryID string, since time.Time) ([]byte, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.names[attribute+":"+countryID+":"+name]
+	if !ok || item.fetchedAt.Before(since) {
+		return nil, time.Time{}, repository.ErrEnrichmentNotFound
+	}
+
+	return item.result, item.fetchedAt, nil
+}
+
+func (s *memStorage) SaveNameEnrichment(_ context.Context, name, attribute, countryID string, result []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.names[attribute+":"+countryID+":"+name] = memNameEnrichment{result: result, fetchedAt: time.Now()}
+
+	return nil
+}
+
+func (s *memStorage) AddQuotaUsage(_ context.Context, usage *models.QuotaUsage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := usage.Provider + ":" + usage.Day.Format(time.DateOnly)
+	stored, ok := s.quota[key]
+	if !ok {
+		u := *usage
+		s.quota[key] = &u
+		return nil
+	}
+	stored.Used += usage.Used
+	if usage.Limit != nil {
+		stored.Limit = usage.Limit
+	}
+	if usage.Remaining != nil {
+		stored.Remaining = usage.Remaining
+	}
+	if usage.ResetAt != nil {
+		stored.ResetAt = usage.ResetAt
+	}
+
+	return nil
+}
+
+func (s *memStorage) QuotaUsage(_ context.Context, day time.Time) ([]*models.QuotaUsage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var usages []*models.QuotaUsage
+	for _, u := range s.quota {
+		if u.Day.Equal(day) {
+			usage := *u
+			usages = append(usages, &usage)
+		}
+	}
+
+	return usages, nil
+}
+
+func (s *memStorage) History(_ context.Context, personID int64, limit, offset uint64) ([]*models.HistoryEntry, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]*models.HistoryEntry, 0)
+	for _, e := range s.history {
+		if e.PersonID == personID {
+			entries = append(entries, e)
+		}
+	}
+	total := uint64(len(entries))
+	entries = entries[min(offset, total):]
+
+	return entries[:min(limit, uint64(len(entries)))], total, nil
+}
+
+// record записывает изменение профиля в журнал; вызывается под s.mu
+func (s *memStorage) record(ctx context.Context, action models.HistoryAction, old, new *models.FullPerson) {
+	fields := func(p *models.FullPerson) map[string]any {
+		if p == nil {
+			return map[string]any{}
+		}
+		return map[string]any{
+			"name":              p.Name,
+			"surname":           p.Surname,
+			"patronymic":        deref(p.Patronymic),
+			"age":               deref(p.Age),
+			"gender":            deref(p.Gender),
+			"nationality":       deref(p.Nationality),
+			"enrichment_status": p.EnrichmentStatus,
+			"deleted_at":        deref(p.DeletedAt),
+		}
+	}
+	o, n := fields(old), fields(new)
+
+	changes := make([]models.HistoryChange, 0)
+	for _, field := range []string{"name", "surname", "patronymic", "age", "gender", "nationality", "enrichment_status", "deleted_at"} {
+		if o[field] != n[field] {
+			changes = append(changes, models.HistoryChange{Field: field, Old: o[field], New: n[field]})
+		}
+	}
+	if action == models.HistoryActionUpdate && len(changes) == 0 {
+		return
+	}
+
+	id := old
+	if id == nil {
+		id = new
+	}
+	s.history = append(s.history, &models.HistoryEntry{
+		ID:        int64(len(s.history) + 1),
+		PersonID:  id.ID,
+		Action:    action,
+		Changes:   changes,
+		Actor:     audit.Actor(ctx),
+		Source:    audit.Source(ctx),
+		CreatedAt: time.Now(),
+	})
+}
+
+func deref[T any](v *T) any {
+	if v == nil {
+		return nil
+	}
+
+	return *v
+}
+
+// touch увеличивает версию и время изменения профиля
+func touch(person *models.FullPerson) {
+	person.Version++
+	person.UpdatedAt = time.Now()
+}
+
+func applyUpdate(person *models.FullPerson, update *models.PersonUpdate) {
+	if update.Name != nil {
+		person.Name = *update.Name
+	}
+	if update.Surname != nil {
+		person.Surname = *update.Surname
+	}
+	if update.Patronymic != nil {
+		person.Patronymic = update.Patronymic
+	}
+	if update.Age != nil {
+		person.Age = update.Age
+	}
+	if update.Gender != nil {
+		person.Gender = update.Gender
+	}
+	if update.Nationality != nil {
+		person.Nationality = update.Nationality
+	}
+}
@@ -12,6 +12,10 @@ type Config struct {
 	Env        string `env:"ENV" envDefault:"prod"`
 	Postgres   Postgres
 	HTTPServer HTTPServer
+	Enrichment Enrichment
+	Retention  Retention
+	Pagination Pagination
+	Search     Search
 }
 
 type HTTPServer struct {
@@ -22,6 +26,8 @@ type HTTPServer struct {
 	IdleTimeout time.Duration `env:"HTTP_IDLE_TIMEOUT" envDefault:"60s"`
 	User        string        `env:"HTTP_USER" envDefault:"user"`
 	Password    string        `env:"HTTP_PASSWORD" envDefault:"password"`
+	// RequireIfMatch требует заголовок If-Match при изменении и удалении профиля
+	RequireIfMatch bool `env:"HTTP_REQUIRE_IF_MATCH" envDefault:"false"`
 }
 
 type Postgres struct {
@@ -34,6 +40,116 @@ type Postgres struct {
 	DSN      string
 }
 
+// Retention срок хранения удаленных профилей: по его истечении они очищаются окончательно.
+// Period 0 отключает очистку.
+type Retention struct {
+	Period        time.Duration `env:"RETENTION_PERIOD" envDefault:"720h"`
+	PurgeInterval time.Duration `env:"RETENTION_PURGE_INTERVAL" envDefault:"1h"`
+}
+
+// Pagination настройки курсорной пагинации. CursorSecret ключ подписи курсоров;
+// если он не задан, используется случайный ключ и курсоры перестают действовать после перезапуска.
+type Pagination struct {
+	CursorSecret string `env:"PAGINATION_CURSOR_SECRET"`
+}
+
+// Search настройки поиска профилей по имени. MinScore наименьшая оценка совпадения
+// от 0 до 1, если она не задана в запросе.
+type Search struct {
+	MinScore float64 `env:"SEARCH_MIN_SCORE" envDefault:"0.3"`
+}
+
+const (
+	EnrichmentModeOnline  = "online"
+	EnrichmentModeOffline = "offline"
+)
+
+// Enrichment настройки источников обогащения.
+// В режиме offline вместо публичных API используется локальный справочник имен DatasetPath.
+type Enrichment struct {
+	Mode        string `env:"ENRICHMENT_MODE" envDefault:"online"`
+	DatasetPath string `env:"ENRICHMENT_DATASET_PATH"`
+
+	Agify       EnrichmentProvider `envPrefix:"ENRICHMENT_AGIFY_"`
+	Genderize   EnrichmentProvider `envPrefix:"ENRICHMENT_GENDERIZE_"`
+	Nationalize EnrichmentProvider `envPrefix:"ENRICHMENT_NATIONALIZE_"`
+	Cache       EnrichmentCache
+	Retry       EnrichmentRetry
+	Breaker     EnrichmentBreaker
+	Async       EnrichmentAsync
+	Thresholds  EnrichmentThresholds
+	Batch       EnrichmentBatch
+	Quota       EnrichmentQuota
+}
+
+type EnrichmentProvider struct {
+	URL     string        `env:"URL"`
+	APIKey  string        `env:"API_KEY"`
+	Timeout time.Duration `env:"TIMEOUT" envDefault:"3s"`
+	Enabled bool          `env:"ENABLED" envDefault:"true"`
+}
+
+type EnrichmentCache struct {
+	Enabled     bool          `env:"ENRICHMENT_CACHE_ENABLED" envDefault:"true"`
+	TTL         time.Duration `env:"ENRICHMENT_CACHE_TTL" envDefault:"720h"`
+	NegativeTTL time.Duration `env:"ENRICHMENT_CACHE_NEGATIVE_TTL" envDefault:"1h"`
+	Size        int           `env:"ENRICHMENT_CACHE_SIZE" envDefault:"1000"`
+}
+
+type EnrichmentRetry struct {
+	MaxRetries int           `env:"ENRICHMENT_RETRY_MAX" envDefault:"2"`
+	BaseDelay  time.Duration `env:"ENRICHMENT_RETRY_BASE_DELAY" envDefault:"200ms"`
+	MaxDelay   time.Duration `env:"ENRICHMENT_RETRY_MAX_DELAY" envDefault:"2s"`
+}
+
+type EnrichmentBreaker struct {
+	FailureThreshold int           `env:"ENRICHMENT_BREAKER_FAILURES" envDefault:"5"`
+	OpenTimeout      time.Duration `env:"ENRICHMENT_BREAKER_OPEN_TIMEOUT" envDefault:"30s"`
+}
+
+// EnrichmentAsync настройки фонового обогащения
+type EnrichmentAsync struct {
+	Enabled      bool          `env:"ENRICHMENT_ASYNC_ENABLED" envDefault:"false"`
+	Workers      int           `env:"ENRICHMENT_ASYNC_WORKERS" envDefault:"4"`
+	BatchSize    uint64        `env:"ENRICHMENT_ASYNC_BATCH_SIZE" envDefault:"10"`
+	PollInterval time.Duration `env:"ENRICHMENT_ASYNC_POLL_INTERVAL" envDefault:"1s"`
+	Lease        time.Duration `env:"ENRICHMENT_ASYNC_LEASE" envDefault:"1m"`
+	MaxAttempts  int           `env:"ENRICHMENT_ASYNC_MAX_ATTEMPTS" envDefault:"5"`
+	RetryDelay   time.Duration `env:"ENRICHMENT_ASYNC_RETRY_DELAY" envDefault:"30s"`
+}
+
+// EnrichmentThresholds минимальные вероятность и размер выборки, при которых значение источника принимается
+type EnrichmentThresholds struct {
+	AgeMinProbability         float64 `env:"ENRICHMENT_AGE_MIN_PROBABILITY" envDefault:"0"`
+	AgeMinCount               int64   `env:"ENRICHMENT_AGE_MIN_COUNT" envDefault:"0"`
+	GenderMinProbability      float64 `env:"ENRICHMENT_GENDER_MIN_PROBABILITY" envDefault:"0.7"`
+	GenderMinCount            int64   `env:"ENRICHMENT_GENDER_MIN_COUNT" envDefault:"0"`
+	NationalityMinProbability float64 `env:"ENRICHMENT_NATIONALITY_MIN_PROBABILITY" envDefault:"0"`
+	NationalityMinCount       int64   `env:"ENRICHMENT_NATIONALITY_MIN_COUNT" envDefault:"0"`
+}
+
+// EnrichmentQuota суточный бюджет запросов к публичным API. При исчерпании бюджета
+// профили сохраняются без обогащения и дообогащаются в фоне после сброса квоты.
+type EnrichmentQuota struct {
+	DailyBudget  int64 `env:"ENRICHMENT_QUOTA_DAILY_BUDGET" envDefault:"0"`
+	MinRemaining int64 `env:"ENRICHMENT_QUOTA_MIN_REMAINING" envDefault:"0"`
+}
+
+// EnrichmentBatch настройки объединения запросов к публичным API в пакеты до 10 имен
+type EnrichmentBatch struct {
+	Enabled bool          `env:"ENRICHMENT_BATCH_ENABLED" envDefault:"true"`
+	Window  time.Duration `env:"ENRICHMENT_BATCH_WINDOW" envDefault:"50ms"`
+	Size    int           `env:"ENRICHMENT_BATCH_SIZE" envDefault:"10"`
+}
+
+const (
+	maxEnrichmentBatchSize = 10
+
+	defaultAgifyURL       = "https://api.agify.io/"
+	defaultGenderizeURL   = "https://api.genderize.io/"
+	defaultNationalizeURL = "https://api.nationalize.io/"
+)
+
 func New() (*Config, error) {
 	cfg := &Config{}
 	if err := env.Parse(cfg); err != nil {
@@ -41,6 +157,19 @@ func New() (*Config, error) {
 	}
 	buildAddress(&cfg.HTTPServer)
 	buildDSN(&cfg.Postgres)
+	setEnrichmentDefaults(&cfg.Enrichment)
+
+	if err := validateEnrichment(&cfg.Enrichment); err != nil {
+		return nil, err
+	}
+
+	if err := validateRetention(&cfg.Retention); err != nil {
+		return nil, err
+	}
+
+	if cfg.Search.MinScore < 0 || cfg.Search.MinScore > 1 {
+		return nil, fmt.Errorf("SEARCH_MIN_SCORE must be between 0 and 1")
+	}
 
 	return cfg, nil
 }
@@ -53,3 +182,49 @@ func buildDSN(p *Postgres) {
 	p.DSN = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
 		p.User, p.Password, p.Host, p.Port, p.Db, p.SslMode)
 }
+
+func setEnrichmentDefaults(e *Enrichment) {
+	if e.Agify.URL == "" {
+		e.Agify.URL = defaultAgifyURL
+	}
+	if e.Genderize.URL == "" {
+		e.Genderize.URL = defaultGenderizeURL
+	}
+	if e.Nationalize.URL == "" {
+		e.Nationalize.URL = defaultNationalizeURL
+	}
+}
+
+func validateEnrichment(e *Enrichment) error {
+	switch e.Mode {
+	case EnrichmentModeOnline:
+	case EnrichmentModeOffline:
+		if e.DatasetPath == "" {
+			return fmt.Errorf("ENRICHMENT_DATASET_PATH is required in %s enrichment mode", EnrichmentModeOffline)
+		}
+	default:
+		return fmt.Errorf("unknown enrichment mode %q", e.Mode)
+	}
+
+	if e.Batch.Size < 1 || e.Batch.Size > maxEnrichmentBatchSize {
+		return fmt.Errorf("ENRICHMENT_BATCH_SIZE must be between 1 and %d", maxEnrichmentBatchSize)
+	}
+
+	if e.Quota.DailyBudget < 0 || e.Quota.MinRemaining < 0 {
+		return fmt.Errorf("ENRICHMENT_QUOTA_DAILY_BUDGET and ENRICHMENT_QUOTA_MIN_REMAINING must not be negative")
+	}
+
+	return nil
+}
+
+func validateRetention(r *Retention) error {
+	if r.Period < 0 {
+		return fmt.Errorf("RETENTION_PERIOD must not be negative")
+	}
+
+	if r.Period > 0 && r.PurgeInterval <= 0 {
+		return fmt.Errorf("RETENTION_PURGE_INTERVAL must be positive")
+	}
+
+	return nil
+}
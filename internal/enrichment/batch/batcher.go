@@ -0,0 +1,147 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/enrichment"
+)
+
+// Batcher собирает одновременные запросы к источнику в пакеты: пакет отправляется,
+// когда набирается size запросов или с первого запроса проходит window.
+// Одинаковые запросы внутри пакета отправляются один раз.
+// Пакеты отправляются в контексте ctx, переданном в New, и прерываются с его отменой.
+type Batcher struct {
+	ctx    context.Context
+	log    *slog.Logger
+	next   enrichment.BatchEnricher
+	window time.Duration
+	size   int
+
+	mu      sync.Mutex
+	pending []*call
+	timer   *time.Timer
+	// gen номер накапливаемого пакета; растет при каждой отправке, чтобы таймер
+	// отправленного пакета не забрал следующий раньше его окна
+	gen uint64
+}
+
+type call struct {
+	q    enrichment.Query
+	done chan struct{}
+	res  *enrichment.Result
+	err  error
+}
+
+func New(ctx context.Context, log *slog.Logger, next enrichment.BatchEnricher, window time.Duration, size int) *Batcher {
+	return &Batcher{
+		ctx:    ctx,
+		log:    log,
+		next:   next,
+		window: window,
+		size:   max(size, 1),
+	}
+}
+
+func (b *Batcher) Attribute() enrichment.Attribute {
+	return b.next.Attribute()
+}
+
+func (b *Batcher) Enrich(ctx context.Context, q enrichment.Query) (*enrichment.Result, error) {
+	c := &call{q: q, done: make(chan struct{})}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, c)
+	switch {
+	case len(b.pending) >= b.size:
+		batch := b.takeLocked()
+		b.mu.Unlock()
+		go b.flush(batch)
+	case len(b.pending) == 1:
+		gen := b.gen
+		b.timer = time.AfterFunc(b.window, func() { b.flushPending(gen) })
+		b.mu.Unlock()
+	default:
+		b.mu.Unlock()
+	}
+
+	select {
+	case <-c.done:
+		return c.res, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// flushPending отправляет пакет gen, если он еще не отправлен по размеру
+func (b *Batcher) flushPending(gen uint64) {
+	b.mu.Lock()
+	if b.gen != gen {
+		b.mu.Unlock()
+		return
+	}
+	batch := b.takeLocked()
+	b.mu.Unlock()
+
+	b.flush(batch)
+}
+
+// takeLocked забирает накопленные запросы; вызывается под b.mu
+func (b *Batcher) takeLocked() []*call {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	batch := b.pending
+	b.pending = nil
+	b.gen++
+
+	return batch
+}
+
+func (b *Batcher) flush(batch []*call) {
+	// публичные API принимают одну страну на запрос, поэтому пакет делится по странам
+	groups := make(map[string][]*call)
+	for _, c := range batch {
+		groups[c.q.CountryID] = append(groups[c.q.CountryID], c)
+	}
+
+	for _, group := range groups {
+		go b.send(group)
+	}
+}
+
+func (b *Batcher) send(batch []*call) {
+	const op = "enrichment.batch.Batcher.send"
+
+	qs := make([]enrichment.Query, 0, len(batch))
+	index := make(map[enrichment.Query]int, len(batch))
+	for _, c := range batch {
+		if _, ok := index[c.q]; !ok {
+			index[c.q] = len(qs)
+			qs = append(qs, c.q)
+		}
+	}
+
+	b.log.Debug("Send enrichment batch", slog.String("op", op),
+		slog.String("attribute", string(b.next.Attribute())), slog.String("country_id", batch[0].q.CountryID),
+		slog.Int("names", len(qs)), slog.Int("calls", len(batch)))
+
+	// пакет обслуживает несколько запросов, поэтому не зависит от отмены контекста отдельного из них
+	results, err := b.next.EnrichBatch(b.ctx, qs)
+	if err == nil && len(results) != len(qs) {
+		err = fmt.Errorf("%s: got %d results for %d queries", op, len(results), len(qs))
+	}
+
+	for _, c := range batch {
+		if err != nil {
+			c.err = err
+		} else {
+			c.res = results[index[c.q]]
+		}
+		close(c.done)
+	}
+}
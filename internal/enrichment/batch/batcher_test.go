@@ -0,0 +1,221 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/enrichment"
+)
+
+// fakeEnricher отвечает возрастом, равным длине имени, и запоминает полученные пакеты
+type fakeEnricher struct {
+	mu      sync.Mutex
+	batches [][]enrichment.Query
+	// block задерживает ответ до отмены контекста пакета
+	block bool
+}
+
+func (f *fakeEnricher) Attribute() enrichment.Attribute {
+	return enrichment.AttributeAge
+}
+
+func (f *fakeEnricher) Enrich(ctx context.Context, q enrichment.Query) (*enrichment.Result, error) {
+	results, err := f.EnrichBatch(ctx, []enrichment.Query{q})
+	if err != nil {
+		return nil, err
+	}
+
+	return results[0], nil
+}
+
+func (f *fakeEnricher) EnrichBatch(ctx context.Context, qs []enrichment.Query) ([]*enrichment.Result, error) {
+	f.mu.Lock()
+	f.batches = append(f.batches, qs)
+	f.mu.Unlock()
+
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	results := make([]*enrichment.Result, 0, len(qs))
+	for _, q := range qs {
+		age := int64(len(q.Name))
+		results = append(results, &enrichment.Result{Age: &age})
+	}
+
+	return results, nil
+}
+
+// sizes размеры полученных пакетов по возрастанию
+func (f *fakeEnricher) sizes() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sizes := make([]int, 0, len(f.batches))
+	for _, b := range f.batches {
+		sizes = append(sizes, len(b))
+	}
+	slices.Sort(sizes)
+
+	return sizes
+}
+
+func TestBatcherFlush(t *testing.T) {
+	tests := []struct {
+		name      string
+		window    time.Duration
+		size      int
+		queries   []enrichment.Query
+		wantSizes []int
+	}{
+		{
+			name:      "by size",
+			window:    time.Hour,
+			size:      3,
+			queries:   []enrichment.Query{{Name: "Ann"}, {Name: "Bob"}, {Name: "Christina"}},
+			wantSizes: []int{3},
+		},
+		{
+			name:      "by window",
+			window:    20 * time.Millisecond,
+			size:      10,
+			queries:   []enrichment.Query{{Name: "Ann"}, {Name: "Bob"}},
+			wantSizes: []int{2},
+		},
+		{
+			name:      "duplicates sent once",
+			window:    20 * time.Millisecond,
+			size:      10,
+			queries:   []enrichment.Query{{Name: "Ann"}, {Name: "Ann"}, {Name: "Bob"}},
+			wantSizes: []int{2},
+		},
+		{
+			name:   "split by country",
+			window: time.Hour,
+			size:   4,
+			queries: []enrichment.Query{
+				{Name: "Ann", CountryID: "RU"},
+				{Name: "Bob", CountryID: "UA"},
+				{Name: "Christina", CountryID: "RU"},
+				{Name: "Ann"},
+			},
+			wantSizes: []int{1, 1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &fakeEnricher{}
+			b := New(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), next, tt.window, tt.size)
+
+			var wg sync.WaitGroup
+			for _, q := range tt.queries {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+
+					res, err := b.Enrich(ctx, q)
+					if err != nil {
+						t.Errorf("Enrich(%s): %v", q.Name, err)
+						return
+					}
+					if res.Age == nil || *res.Age != int64(len(q.Name)) {
+						t.Errorf("Enrich(%s) age = %v, want %d", q.Name, res.Age, len(q.Name))
+					}
+				}()
+			}
+			wg.Wait()
+
+			if sizes := next.sizes(); !slices.Equal(sizes, tt.wantSizes) {
+				t.Errorf("batch sizes = %v, want %v", sizes, tt.wantSizes)
+			}
+		})
+	}
+}
+
+func TestBatcherWaitsWindowForNextBatch(t *testing.T) {
+	const window = 100 * time.Millisecond
+
+	next := &fakeEnricher{}
+	b := New(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), next, window, 2)
+
+	// пакет из двух запросов отправляется по размеру, не дожидаясь окна
+	var wg sync.WaitGroup
+	for _, name := range []string{"Ann", "Bob"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := b.Enrich(context.Background(), enrichment.Query{Name: name}); err != nil {
+				t.Errorf("Enrich(%s): %v", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	start := time.Now()
+	if _, err := b.Enrich(context.Background(), enrichment.Query{Name: "Christina"}); err != nil {
+		t.Fatalf("Enrich: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < window {
+		t.Errorf("next batch sent after %s, want at least the %s window", elapsed, window)
+	}
+	if sizes := next.sizes(); !slices.Equal(sizes, []int{1, 2}) {
+		t.Errorf("batch sizes = %v, want [1 2]", sizes)
+	}
+}
+
+func TestBatcherStopsWithLifecycleContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	next := &fakeEnricher{block: true}
+	b := New(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), next, time.Millisecond, 10)
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := b.Enrich(context.Background(), enrichment.Query{Name: "Ann"})
+		errc <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Enrich error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Enrich did not return after the batcher context was cancelled")
+	}
+}
+
+func TestBatcherIgnoresCallerCancellation(t *testing.T) {
+	next := &fakeEnricher{}
+	b := New(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), next, 50*time.Millisecond, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.Enrich(ctx, enrichment.Query{Name: "Ann"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled Enrich error = %v, want %v", err, context.Canceled)
+	}
+
+	// отмена одного запроса не прерывает пакет, в который попали другие
+	res, err := b.Enrich(context.Background(), enrichment.Query{Name: "Bob"})
+	if err != nil {
+		t.Fatalf("Enrich: %v", err)
+	}
+	if res.Age == nil || *res.Age != 3 {
+		t.Errorf("age = %v, want 3", res.Age)
+	}
+	if sizes := next.sizes(); !slices.Equal(sizes, []int{2}) {
+		t.Errorf("batch sizes = %v, want [2]", sizes)
+	}
+}
@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/enrichment"
+	"github.com/Gustcat/people-info-service/internal/lib/lru"
+	"github.com/Gustcat/people-info-service/internal/repository"
+)
+
+// Store постоянное хранилище результатов обогащения по имени
+type Store interface {
+	GetNameEnrichment(ctx context.Context, name, attribute, countryID string, since time.Time) ([]byte, time.Time, error)
+	SaveNameEnrichment(ctx context.Context, name, attribute, countryID string, result []byte) error
+}
+
+// Stats статистика обращений к кэшу
+type Stats struct {
+	MemoryHits uint64 `json:"memory_hits"`
+	StoreHits  uint64 `json:"store_hits"`
+	Misses     uint64 `json:"misses"`
+	Size       int    `json:"size"`
+}
+
+// Cache двухуровневый кэш обогащения: LRU в памяти перед таблицей name_enrichment.
+// Результаты без значения атрибута хранятся negativeTTL, чтобы источник, еще не знающий
+// имени, был спрошен снова раньше, чем истечет ttl.
+type Cache struct {
+	log         *slog.Logger
+	store       Store
+	ttl         time.Duration
+	negativeTTL time.Duration
+	items       *lru.Cache[string, cached]
+
+	memoryHits atomic.Uint64
+	storeHits  atomic.Uint64
+	misses     atomic.Uint64
+}
+
+type cached struct {
+	result    *enrichment.Result
+	fetchedAt time.Time
+}
+
+func New(log *slog.Logger, store Store, ttl, negativeTTL time.Duration, size int) *Cache {
+	return &Cache{
+		log:         log,
+		store:       store,
+		ttl:         ttl,
+		negativeTTL: min(negativeTTL, ttl),
+		items:       lru.New[string, cached](size),
+	}
+}
+
+// Wrap возвращает источник, который обращается к next только при промахе кэша
+func (c *Cache) Wrap(next enrichment.Enricher) enrichment.Enricher {
+	return &cachedEnricher{cache: c, next: next}
+}
+
+func (c *Cache) Stats() *Stats {
+	return &Stats{
+		MemoryHits: c.memoryHits.Load(),
+		StoreHits:  c.storeHits.Load(),
+		Misses:     c.misses.Load(),
+		Size:       c.items.Len(),
+	}
+}
+
+func (c *Cache) get(ctx context.Context, attr enrichment.Attribute, q enrichment.Query) (*enrichment.Result, bool) {
+	const op = "enrichment.cache.Cache.get"
+
+	key := cacheKey(attr, q)
+	if item, ok := c.items.Get(key); ok {
+		if c.fresh(attr, item.result, item.fetchedAt) {
+			c.memoryHits.Add(1)
+			return item.result, true
+		}
+		c.items.Remove(key)
+	}
+
+	raw, fetchedAt, err := c.store.GetNameEnrichment(ctx, q.Name, string(attr), q.CountryID, time.Now().Add(-c.ttl))
+	if err != nil {
+		if !errors.Is(err, repository.ErrEnrichmentNotFound) {
+			c.log.Error("Failed to read enrichment cache",
+				slog.String("op", op), slog.String("error", err.Error()))
+		}
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	var res enrichment.Result
+	if err := json.Unmarshal(raw, &res); err != nil {
+		c.log.Error("Failed to decode cached enrichment",
+			slog.String("op", op), slog.String("error", err.Error()))
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	if !c.fresh(attr, &res, fetchedAt) {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.storeHits.Add(1)
+	c.items.Add(key, cached{result: &res, fetchedAt: fetchedAt})
+
+	return &res, true
+}
+
+// fresh сообщает, не истек ли срок хранения результата res, полученного в fetchedAt
+func (c *Cache) fresh(attr enrichment.Attribute, res *enrichment.Result, fetchedAt time.Time) bool {
+	ttl := c.ttl
+	if res.Empty(attr) {
+		ttl = c.negativeTTL
+	}
+
+	return time.Since(fetchedAt) < ttl
+}
+
+func (c *Cache) set(ctx context.Context, attr enrichment.Attribute, q enrichment.Query, res *enrichment.Result) error {
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Errorf("encoding result failed: %w", err)
+	}
+
+	c.items.Add(cacheKey(attr, q), cached{result: res, fetchedAt: time.Now()})
+
+	return c.store.SaveNameEnrichment(ctx, q.Name, string(attr), q.CountryID, raw)
+}
+
+type cachedEnricher struct {
+	cache *Cache
+	next  enrichment.Enricher
+}
+
+func (e *cachedEnricher) Attribute() enrichment.Attribute {
+	return e.next.Attribute()
+}
+
+func (e *cachedEnricher) Enrich(ctx context.Context, q enrichment.Query) (*enrichment.Result, error) {
+	const op = "enrichment.cache.Enrich"
+
+	key := enrichment.Query{Name: normalizeName(q.Name), CountryID: strings.ToUpper(q.CountryID)}
+	attr := e.next.Attribute()
+
+	if res, ok := e.cache.get(ctx, attr, key); ok {
+		return res, nil
+	}
+
+	res, err := e.next.Enrich(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.cache.set(ctx, attr, key, res); err != nil {
+		e.cache.log.Error("Failed to save enrichment to cache",
+			slog.String("op", op), slog.String("error", err.Error()))
+	}
+
+	return res, nil
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func cacheKey(attr enrichment.Attribute, q enrichment.Query) string {
+	return string(attr) + ":" + q.CountryID + ":" + q.Name
+}
@@ -0,0 +1,250 @@
+package dataset
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/enrichment"
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+const Name = "dataset"
+
+var ErrUnsupportedFormat = errors.New("unsupported dataset format")
+
+// Record сведения об имени: средний возраст, распределения по полу и странам
+type Record struct {
+	Name      string                    `json:"name"`
+	Age       *float64                  `json:"age"`
+	Count     *int64                    `json:"count"`
+	Gender    map[models.Gender]float64 `json:"gender"`
+	Countries map[string]float64        `json:"countries"`
+}
+
+// Dataset локальный справочник имен для обогащения без доступа к публичным API
+type Dataset struct {
+	records  map[string]*Record
+	loadedAt time.Time
+}
+
+// Load читает справочник из JSON-массива записей или CSV-файла с заголовком
+//
+//	name,age,count,male,female,countries
+//	ivan,42.5,1200,0.99,0.01,RU:0.61;UA:0.2;BY:0.1
+func Load(path string) (*Dataset, error) {
+	const op = "enrichment.dataset.Load"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	var records []*Record
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&records)
+	case ".csv":
+		records, err = readCSV(f)
+	default:
+		err = ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	d := &Dataset{
+		records:  make(map[string]*Record, len(records)),
+		loadedAt: time.Now(),
+	}
+	for _, r := range records {
+		d.records[normalizeName(r.Name)] = r
+	}
+
+	return d, nil
+}
+
+func (d *Dataset) Len() int {
+	return len(d.records)
+}
+
+// Enrichers возвращает источники возраста, пола и национальности на основе справочника
+func (d *Dataset) Enrichers() []enrichment.Enricher {
+	return []enrichment.Enricher{
+		&enricher{dataset: d, attr: enrichment.AttributeAge},
+		&enricher{dataset: d, attr: enrichment.AttributeGender},
+		&enricher{dataset: d, attr: enrichment.AttributeNationality},
+	}
+}
+
+type enricher struct {
+	dataset *Dataset
+	attr    enrichment.Attribute
+}
+
+func (e *enricher) Attribute() enrichment.Attribute {
+	return e.attr
+}
+
+// Enrich отвечает так же, как публичные API: неизвестное имя дает пустое значение без ошибки
+func (e *enricher) Enrich(_ context.Context, q enrichment.Query) (*enrichment.Result, error) {
+	res := &enrichment.Result{
+		Source:    Name,
+		FetchedAt: e.dataset.loadedAt,
+	}
+
+	record, ok := e.dataset.records[normalizeName(q.Name)]
+	if !ok {
+		return res, nil
+	}
+	res.Count = record.Count
+
+	switch e.attr {
+	case enrichment.AttributeAge:
+		if record.Age != nil {
+			age := int64(math.Round(*record.Age))
+			res.Age = &age
+		}
+	case enrichment.AttributeGender:
+		if gender, probability, ok := top(record.Gender); ok {
+			res.Gender = &gender
+			res.Probability = &probability
+		}
+	case enrichment.AttributeNationality:
+		res.Candidates = make([]models.NationalityCandidate, 0, len(record.Countries))
+		for countryID, probability := range record.Countries {
+			res.Candidates = append(res.Candidates, models.NationalityCandidate{
+				CountryID:   countryID,
+				Probability: probability,
+			})
+		}
+		sort.Slice(res.Candidates, func(i, j int) bool {
+			return res.Candidates[i].Probability > res.Candidates[j].Probability
+		})
+		if countryID, probability, ok := top(record.Countries); ok {
+			res.Nationality = &countryID
+			res.Probability = &probability
+		}
+	}
+
+	return res, nil
+}
+
+// top возвращает значение с наибольшей вероятностью; при равенстве выбирается меньшее значение
+func top[K ~string](distribution map[K]float64) (K, float64, bool) {
+	var (
+		best        K
+		probability float64
+		found       bool
+	)
+
+	for k, p := range distribution {
+		if !found || p > probability || (p == probability && k < best) {
+			best, probability, found = k, p, true
+		}
+	}
+
+	return best, probability, found
+}
+
+func readCSV(r io.Reader) ([]*Record, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header failed: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("column name is required")
+	}
+
+	field := func(row []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	records := make([]*Record, 0)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading row failed: %w", err)
+		}
+
+		record := &Record{
+			Name:      field(row, "name"),
+			Gender:    make(map[models.Gender]float64, 2),
+			Countries: make(map[string]float64),
+		}
+
+		if v := field(row, "age"); v != "" {
+			age, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid age for %s: %w", record.Name, err)
+			}
+			record.Age = &age
+		}
+
+		if v := field(row, "count"); v != "" {
+			count, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid count for %s: %w", record.Name, err)
+			}
+			record.Count = &count
+		}
+
+		for _, gender := range []models.Gender{models.GenderMale, models.GenderFemale} {
+			if v := field(row, string(gender)); v != "" {
+				p, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s probability for %s: %w", gender, record.Name, err)
+				}
+				record.Gender[gender] = p
+			}
+		}
+
+		if v := field(row, "countries"); v != "" {
+			for _, pair := range strings.Split(v, ";") {
+				countryID, p, ok := strings.Cut(pair, ":")
+				if !ok {
+					return nil, fmt.Errorf("invalid country %q for %s", pair, record.Name)
+				}
+				probability, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid country probability for %s: %w", record.Name, err)
+				}
+				record.Countries[strings.ToUpper(strings.TrimSpace(countryID))] = probability
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
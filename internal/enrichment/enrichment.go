@@ -0,0 +1,144 @@
+package enrichment
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+// Attribute атрибут профиля, которым обогащается запись
+type Attribute string
+
+const (
+	AttributeAge         Attribute = "age"
+	AttributeGender      Attribute = "gender"
+	AttributeNationality Attribute = "nationality"
+)
+
+// Query параметры запроса к источнику обогащения.
+// CountryID уточняет запрос для страны, если источник это поддерживает.
+type Query struct {
+	Name      string
+	CountryID string
+}
+
+// Result значение атрибута, полученное от источника.
+// Из значений заполняется только поле, соответствующее атрибуту источника.
+type Result struct {
+	Age         *int64         `json:"age,omitempty"`
+	Gender      *models.Gender `json:"gender,omitempty"`
+	Nationality *string        `json:"nationality,omitempty"`
+
+	// Candidates все национальности, предложенные источником
+	Candidates []models.NationalityCandidate `json:"candidates,omitempty"`
+
+	// CountryID страна, для которой источник уточнял значение
+	CountryID string `json:"country_id,omitempty"`
+
+	Source      string    `json:"source"`
+	Probability *float64  `json:"probability,omitempty"`
+	Count       *int64    `json:"count,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+const (
+	RejectReasonLowProbability = "low_probability"
+	RejectReasonLowCount       = "low_count"
+)
+
+// Threshold минимальная достоверность значения атрибута.
+// Проверки пропускаются, если источник не сообщает вероятность или размер выборки.
+type Threshold struct {
+	MinProbability float64
+	MinCount       int64
+}
+
+// check возвращает причину, по которой результат не проходит порог, или пустую строку
+func (t Threshold) check(res *Result) string {
+	if res.Probability != nil && *res.Probability < t.MinProbability {
+		return RejectReasonLowProbability
+	}
+	if res.Count != nil && *res.Count < t.MinCount {
+		return RejectReasonLowCount
+	}
+
+	return ""
+}
+
+// Enricher источник данных для одного атрибута профиля
+type Enricher interface {
+	Attribute() Attribute
+	Enrich(ctx context.Context, q Query) (*Result, error)
+}
+
+// BatchEnricher источник, отвечающий на несколько запросов одним обращением.
+// Результаты возвращаются в порядке запросов.
+type BatchEnricher interface {
+	Enricher
+	EnrichBatch(ctx context.Context, qs []Query) ([]*Result, error)
+}
+
+// Empty сообщает, что источник не нашел значения атрибута
+func (res *Result) Empty(attr Attribute) bool {
+	return res == nil || res.value(attr) == nil
+}
+
+// value возвращает строковое представление значения атрибута
+func (res *Result) value(attr Attribute) *string {
+	var v string
+
+	switch {
+	case attr == AttributeAge && res.Age != nil:
+		v = strconv.FormatInt(*res.Age, 10)
+	case attr == AttributeGender && res.Gender != nil:
+		v = string(*res.Gender)
+	case attr == AttributeNationality && res.Nationality != nil:
+		v = *res.Nationality
+	default:
+		return nil
+	}
+
+	return &v
+}
+
+// apply записывает значение атрибута и его происхождение в профиль.
+// Если указана причина отказа, значение не записывается, а сохраняется в происхождении как отброшенное.
+func (res *Result) apply(attr Attribute, person *models.EnrichmentPerson, rejectReason string) {
+	fetchedAt := res.FetchedAt
+	provenance := &models.Provenance{
+		Source:      res.Source,
+		Confidence:  res.Probability,
+		SampleCount: res.Count,
+		FetchedAt:   &fetchedAt,
+	}
+	if res.CountryID != "" {
+		countryID := res.CountryID
+		provenance.CountryID = &countryID
+	}
+
+	if person.Provenance == nil {
+		person.Provenance = make(map[string]*models.Provenance)
+	}
+	person.Provenance[string(attr)] = provenance
+
+	if attr == AttributeNationality {
+		person.NationalityCandidates = res.Candidates
+	}
+
+	if rejectReason != "" {
+		provenance.RejectedValue = res.value(attr)
+		provenance.RejectedReason = &rejectReason
+		return
+	}
+
+	switch attr {
+	case AttributeAge:
+		person.Age = res.Age
+	case AttributeGender:
+		person.Gender = res.Gender
+	case AttributeNationality:
+		person.Nationality = res.Nationality
+	}
+}
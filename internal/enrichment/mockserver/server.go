@@ -0,0 +1,277 @@
+// Package mockserver имитирует agify, genderize и nationalize для тестов:
+// ответы задаются по имени, в том числе задержки, ошибки и некорректный JSON.
+package mockserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Provider string
+
+const (
+	Agify       Provider = "agify"
+	Genderize   Provider = "genderize"
+	Nationalize Provider = "nationalize"
+)
+
+const (
+	nameParam      = "name"
+	batchNameParam = "name[]"
+	countryParam   = "country_id"
+)
+
+// Response сценарий ответа на запрос одного имени.
+// Если Body пустой, отдается Data в формате JSON.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   string
+	Data   any
+	Delay  time.Duration
+}
+
+// Country кандидат национальности в ответе nationalize
+type Country struct {
+	CountryID   string  `json:"country_id"`
+	Probability float64 `json:"probability"`
+}
+
+// Age ответ agify
+func Age(age, count int64) Response {
+	return Response{Data: map[string]any{"age": age, "count": count}}
+}
+
+// Gender ответ genderize
+func Gender(gender string, probability float64, count int64) Response {
+	return Response{Data: map[string]any{"gender": gender, "probability": probability, "count": count}}
+}
+
+// Nationality ответ nationalize
+func Nationality(count int64, countries ...Country) Response {
+	return Response{Data: map[string]any{"country": countries, "count": count}}
+}
+
+// Status ответ с кодом ошибки
+func Status(code int) Response {
+	return Response{Status: code, Body: http.StatusText(code)}
+}
+
+// TooManyRequests ответ 429 с заголовком Retry-After
+func TooManyRequests(retryAfter string) Response {
+	return Response{
+		Status: http.StatusTooManyRequests,
+		Header: http.Header{"Retry-After": {retryAfter}},
+		Body:   `{"error":"Request limit reached"}`,
+	}
+}
+
+// Malformed ответ 200 с некорректным JSON
+func Malformed() Response {
+	return Response{Body: `{"name":`}
+}
+
+// Slow задерживает ответ на delay
+func Slow(resp Response, delay time.Duration) Response {
+	resp.Delay = delay
+	return resp
+}
+
+// WithHeader добавляет к ответу заголовок, например X-Rate-Limit-Remaining
+func WithHeader(resp Response, key, value string) Response {
+	header := resp.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set(key, value)
+	resp.Header = header
+
+	return resp
+}
+
+// Request запрос, полученный сервером
+type Request struct {
+	Provider Provider
+	Query    url.Values
+}
+
+// Server HTTP-сервер с имитацией публичных API обогащения.
+// Каждый источник доступен по адресу URL(provider).
+type Server struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	scripts  map[Provider]map[string][]Response
+	requests []Request
+}
+
+// New запускает сервер; по умолчанию на любое имя отвечает как на неизвестное
+func New() *Server {
+	s := &Server{
+		scripts: make(map[Provider]map[string][]Response),
+	}
+
+	mux := http.NewServeMux()
+	for _, p := range []Provider{Agify, Genderize, Nationalize} {
+		mux.HandleFunc("/"+string(p)+"/", func(w http.ResponseWriter, r *http.Request) {
+			s.serve(p, w, r)
+		})
+	}
+	s.srv = httptest.NewServer(mux)
+
+	return s
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// URL базовый адрес источника
+func (s *Server) URL(p Provider) string {
+	return s.srv.URL + "/" + string(p) + "/"
+}
+
+// Handle задает ответы источника на имя: ответы отдаются по очереди, последний повторяется.
+// Пустое имя задает ответ для всех имен без собственного сценария.
+func (s *Server) Handle(p Provider, name string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.scripts[p] == nil {
+		s.scripts[p] = make(map[string][]Response)
+	}
+	s.scripts[p][strings.ToLower(name)] = responses
+}
+
+// Requests возвращает запросы, полученные источником
+func (s *Server) Requests(p Provider) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []Request
+	for _, req := range s.requests {
+		if req.Provider == p {
+			requests = append(requests, req)
+		}
+	}
+
+	return requests
+}
+
+func (s *Server) serve(p Provider, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	names, batch := query[batchNameParam]
+	if !batch {
+		names = []string{query.Get(nameParam)}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Provider: p, Query: query})
+	responses := make([]Response, 0, len(names))
+	for _, name := range names {
+		responses = append(responses, s.next(p, name))
+	}
+	s.mu.Unlock()
+
+	var delay time.Duration
+	for _, resp := range responses {
+		delay = max(delay, resp.Delay)
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	// ошибка любого имени в пакете возвращается на весь запрос, как у публичных API
+	for _, resp := range responses {
+		if (resp.Status != 0 && resp.Status != http.StatusOK) || resp.Body != "" {
+			write(w, resp, []byte(resp.Body))
+			return
+		}
+	}
+
+	header := make(http.Header)
+	data := make([]map[string]any, 0, len(responses))
+	for i, resp := range responses {
+		for k, values := range resp.Header {
+			header[k] = values
+		}
+
+		item := map[string]any{nameParam: names[i]}
+		if countryID := query.Get(countryParam); countryID != "" && p != Nationalize {
+			item[countryParam] = countryID
+		}
+		if fields, ok := resp.Data.(map[string]any); ok {
+			for k, v := range fields {
+				item[k] = v
+			}
+		}
+		data = append(data, item)
+	}
+
+	var body []byte
+	if batch {
+		body, _ = json.Marshal(data)
+	} else {
+		body, _ = json.Marshal(data[0])
+	}
+	write(w, Response{Header: header}, body)
+}
+
+// next возвращает очередной ответ на имя; вызывается под s.mu
+func (s *Server) next(p Provider, name string) Response {
+	scripts := s.scripts[p]
+	key := strings.ToLower(name)
+	queue, ok := scripts[key]
+	if !ok {
+		key = ""
+		queue, ok = scripts[key]
+	}
+	if !ok || len(queue) == 0 {
+		return Unknown(p)
+	}
+
+	resp := queue[0]
+	if len(queue) > 1 {
+		scripts[key] = queue[1:]
+	}
+
+	return resp
+}
+
+func write(w http.ResponseWriter, resp Response, body []byte) {
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// Unknown ответ источника на имя, которого нет в его базе
+func Unknown(p Provider) Response {
+	switch p {
+	case Agify:
+		return Response{Data: map[string]any{"age": nil, "count": 0}}
+	case Genderize:
+		return Response{Data: map[string]any{"gender": nil, "probability": 0.0, "count": 0}}
+	default:
+		return Response{Data: map[string]any{"country": []Country{}, "count": 0}}
+	}
+}
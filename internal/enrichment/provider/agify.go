@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/enrichment"
+)
+
+const AgifyName = "agify"
+
+// Agify определяет возраст по имени через api.agify.io
+type Agify struct {
+	endpoint
+}
+
+type agifyResponse struct {
+	Age       *int64  `json:"age"`
+	Count     *int64  `json:"count"`
+	CountryID *string `json:"country_id"`
+}
+
+func NewAgify(client Doer, baseURL, apiKey string) *Agify {
+	return &Agify{endpoint{client: client, baseURL: baseURL, apiKey: apiKey}}
+}
+
+func (a *Agify) Attribute() enrichment.Attribute {
+	return enrichment.AttributeAge
+}
+
+func (a *Agify) Enrich(ctx context.Context, q enrichment.Query) (*enrichment.Result, error) {
+	const op = "enrichment.provider.Agify.Enrich"
+
+	var data agifyResponse
+	if err := a.getJSON(ctx, localizedParams(singleParams(q), q.CountryID), &data); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data.result(), nil
+}
+
+func (a *Agify) EnrichBatch(ctx context.Context, qs []enrichment.Query) ([]*enrichment.Result, error) {
+	const op = "enrichment.provider.Agify.EnrichBatch"
+
+	params, err := batchParams(qs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	countryID, err := batchCountry(qs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var data []agifyResponse
+	if err := a.getJSON(ctx, localizedParams(params, countryID), &data); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	results, err := batchResults(qs, data, (*agifyResponse).result)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+func (data *agifyResponse) result() *enrichment.Result {
+	res := &enrichment.Result{
+		Age:       data.Age,
+		Source:    AgifyName,
+		Count:     data.Count,
+		FetchedAt: time.Now(),
+	}
+	if data.CountryID != nil {
+		res.CountryID = *data.CountryID
+	}
+
+	return res
+}
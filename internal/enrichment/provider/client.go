@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Gustcat/people-info-service/internal/enrichment"
+	"github.com/Gustcat/people-info-service/internal/lib/urlbuilder"
+)
+
+const (
+	nameParam      = "name"
+	batchNameParam = "name[]"
+	apiKeyParam    = "apikey"
+	countryParam   = "country_id"
+
+	// MaxBatchSize максимальное число имен в одном запросе к публичным API
+	MaxBatchSize = 10
+)
+
+// Doer выполняет HTTP-запрос; реализуется *http.Client и httpclient.Client
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// endpoint общий HTTP-клиент публичных API обогащения
+type endpoint struct {
+	client  Doer
+	baseURL string
+	apiKey  string
+}
+
+// getJSON выполняет GET-запрос к baseURL с параметрами и декодирует JSON-ответ в dst
+func (e *endpoint) getJSON(ctx context.Context, params url.Values, dst any) error {
+	if e.apiKey != "" {
+		params.Set(apiKeyParam, e.apiKey)
+	}
+
+	fullURL, err := urlbuilder.BuildWithQueryValues(e.baseURL, params)
+	if err != nil {
+		return fmt.Errorf("building url failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request failed: %w", err)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("decoding response body failed: %w", err)
+	}
+
+	return nil
+}
+
+func singleParams(q enrichment.Query) url.Values {
+	return url.Values{nameParam: {q.Name}}
+}
+
+func batchParams(qs []enrichment.Query) (url.Values, error) {
+	if len(qs) > MaxBatchSize {
+		return nil, fmt.Errorf("batch size %d exceeds limit %d", len(qs), MaxBatchSize)
+	}
+
+	names := make([]string, 0, len(qs))
+	for _, q := range qs {
+		names = append(names, q.Name)
+	}
+
+	return url.Values{batchNameParam: names}, nil
+}
+
+// localizedParams добавляет к параметрам страну, для которой уточняется результат
+func localizedParams(params url.Values, countryID string) url.Values {
+	if countryID != "" {
+		params.Set(countryParam, countryID)
+	}
+
+	return params
+}
+
+// batchCountry возвращает страну пакета: публичные API принимают одну страну на запрос
+func batchCountry(qs []enrichment.Query) (string, error) {
+	if len(qs) == 0 {
+		return "", nil
+	}
+
+	countryID := qs[0].CountryID
+	for _, q := range qs[1:] {
+		if q.CountryID != countryID {
+			return "", fmt.Errorf("batch mixes countries %q and %q", countryID, q.CountryID)
+		}
+	}
+
+	return countryID, nil
+}
+
+// batchResults сопоставляет ответы пакетного запроса с запросами по порядку
+func batchResults[T any](qs []enrichment.Query, data []T, result func(*T) *enrichment.Result) ([]*enrichment.Result, error) {
+	if len(data) != len(qs) {
+		return nil, fmt.Errorf("got %d results for %d names", len(data), len(qs))
+	}
+
+	results := make([]*enrichment.Result, 0, len(data))
+	for i := range data {
+		results = append(results, result(&data[i]))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/enrichment"
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+const GenderizeName = "genderize"
+
+// Genderize определяет пол по имени через api.genderize.io
+type Genderize struct {
+	endpoint
+}
+
+type genderizeResponse struct {
+	Gender      *models.Gender `json:"gender"`
+	Probability float64        `json:"probability"`
+	Count       *int64         `json:"count"`
+	CountryID   *string        `json:"country_id"`
+}
+
+func NewGenderize(client Doer, baseURL, apiKey string) *Genderize {
+	return &Genderize{endpoint{client: client, baseURL: baseURL, apiKey: apiKey}}
+}
+
+func (g *Genderize) Attribute() enrichment.Attribute {
+	return enrichment.AttributeGender
+}
+
+func (g *Genderize) Enrich(ctx context.Context, q enrichment.Query) (*enrichment.Result, error) {
+	const op = "enrichment.provider.Genderize.Enrich"
+
+	var data genderizeResponse
+	if err := g.getJSON(ctx, localizedParams(singleParams(q), q.CountryID), &data); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data.result(), nil
+}
+
+func (g *Genderize) EnrichBatch(ctx context.Context, qs []enrichment.Query) ([]*enrichment.Result, error) {
+	const op = "enrichment.provider.Genderize.EnrichBatch"
+
+	params, err := batchParams(qs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	countryID, err := batchCountry(qs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var data []genderizeResponse
+	if err := g.getJSON(ctx, localizedParams(params, countryID), &data); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	results, err := batchResults(qs, data, (*genderizeResponse).result)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+func (data *genderizeResponse) result() *enrichment.Result {
+	res := &enrichment.Result{
+		Gender:      data.Gender,
+		Source:      GenderizeName,
+		Probability: &data.Probability,
+		Count:       data.Count,
+		FetchedAt:   time.Now(),
+	}
+	if data.CountryID != nil {
+		res.CountryID = *data.CountryID
+	}
+
+	return res
+}
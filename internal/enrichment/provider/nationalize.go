@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/enrichment"
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+const NationalizeName = "nationalize"
+
+// Nationalize определяет национальность по имени через api.nationalize.io
+type Nationalize struct {
+	endpoint
+}
+
+type nationalizeResponse struct {
+	Country []struct {
+		CountryID   string  `json:"country_id"`
+		Probability float64 `json:"probability"`
+	} `json:"country"`
+	Count *int64 `json:"count"`
+}
+
+func NewNationalize(client Doer, baseURL, apiKey string) *Nationalize {
+	return &Nationalize{endpoint{client: client, baseURL: baseURL, apiKey: apiKey}}
+}
+
+func (n *Nationalize) Attribute() enrichment.Attribute {
+	return enrichment.AttributeNationality
+}
+
+func (n *Nationalize) Enrich(ctx context.Context, q enrichment.Query) (*enrichment.Result, error) {
+	const op = "enrichment.provider.Nationalize.Enrich"
+
+	var data nationalizeResponse
+	if err := n.getJSON(ctx, singleParams(q), &data); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data.result(), nil
+}
+
+func (n *Nationalize) EnrichBatch(ctx context.Context, qs []enrichment.Query) ([]*enrichment.Result, error) {
+	const op = "enrichment.provider.Nationalize.EnrichBatch"
+
+	params, err := batchParams(qs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var data []nationalizeResponse
+	if err := n.getJSON(ctx, params, &data); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	results, err := batchResults(qs, data, (*nationalizeResponse).result)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+func (data *nationalizeResponse) result() *enrichment.Result {
+	var nationality *string
+	probability := 0.0
+	candidates := make([]models.NationalityCandidate, 0, len(data.Country))
+
+	for _, version := range data.Country {
+		candidates = append(candidates, models.NationalityCandidate{
+			CountryID:   version.CountryID,
+			Probability: version.Probability,
+		})
+		if version.Probability > probability {
+			nationality = &version.CountryID
+			probability = version.Probability
+		}
+	}
+
+	res := &enrichment.Result{
+		Nationality: nationality,
+		Candidates:  candidates,
+		Source:      NationalizeName,
+		Count:       data.Count,
+		FetchedAt:   time.Now(),
+	}
+	if nationality != nil {
+		res.Probability = &probability
+	}
+
+	return res
+}
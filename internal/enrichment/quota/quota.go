@@ -0,0 +1,263 @@
+package quota
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+const (
+	headerLimit     = "X-Rate-Limit-Limit"
+	headerRemaining = "X-Rate-Limit-Remaining"
+	headerReset     = "X-Rate-Limit-Reset"
+
+	nameParam      = "name"
+	batchNameParam = "name[]"
+)
+
+// Store постоянное хранилище суточных счетчиков запросов к источникам
+type Store interface {
+	AddQuotaUsage(ctx context.Context, usage *models.QuotaUsage) error
+	QuotaUsage(ctx context.Context, day time.Time) ([]*models.QuotaUsage, error)
+}
+
+// Usage расход квоты источника за текущие сутки (UTC)
+type Usage struct {
+	Provider  string     `json:"provider"`
+	Day       string     `json:"day"`
+	Used      int64      `json:"used"`
+	Budget    int64      `json:"budget,omitempty"`
+	Limit     *int64     `json:"limit,omitempty"`
+	Remaining *int64     `json:"remaining,omitempty"`
+	ResetAt   *time.Time `json:"reset_at,omitempty"`
+	Exhausted bool       `json:"exhausted"`
+}
+
+// Tracker считает имена, запрошенные у источников, и остаток квоты из заголовков
+// X-Rate-Limit-*. Бюджет считается исчерпанным, если за сутки запрошено budget имен
+// или источник сообщил остаток не больше minRemaining.
+type Tracker struct {
+	log          *slog.Logger
+	store        Store
+	budget       int64
+	minRemaining int64
+
+	mu        sync.Mutex
+	providers map[string]*state
+}
+
+type state struct {
+	day       time.Time
+	used      int64
+	limit     *int64
+	remaining *int64
+	resetAt   *time.Time
+}
+
+// New создает счетчик; budget 0 снимает ограничение на число имен в сутки
+func New(log *slog.Logger, store Store, budget, minRemaining int64) *Tracker {
+	return &Tracker{
+		log:          log,
+		store:        store,
+		budget:       budget,
+		minRemaining: minRemaining,
+		providers:    make(map[string]*state),
+	}
+}
+
+// Load восстанавливает счетчики текущих суток из хранилища
+func (t *Tracker) Load(ctx context.Context) error {
+	day := today()
+	usages, err := t.store.QuotaUsage(ctx, day)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, u := range usages {
+		s := t.stateLocked(u.Provider, day)
+		s.used = u.Used
+		s.limit = u.Limit
+		s.remaining = u.Remaining
+		s.resetAt = u.ResetAt
+	}
+
+	return nil
+}
+
+// Transport учитывает запросы к источнику provider, выполняемые через next
+func (t *Tracker) Transport(provider string, next http.RoundTripper) http.RoundTripper {
+	t.mu.Lock()
+	t.stateLocked(provider, today())
+	t.mu.Unlock()
+
+	return &transport{tracker: t, provider: provider, next: next}
+}
+
+// Exhausted сообщает, исчерпан ли бюджет хотя бы одного источника
+func (t *Tracker) Exhausted() bool {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for name := range t.providers {
+		if t.exhaustedLocked(t.stateLocked(name, now), now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Usage возвращает расход квоты источников, упорядоченный по названию
+func (t *Tracker) Usage() []Usage {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usages := make([]Usage, 0, len(t.providers))
+	for name := range t.providers {
+		s := t.stateLocked(name, now)
+		usages = append(usages, Usage{
+			Provider:  name,
+			Day:       s.day.Format(time.DateOnly),
+			Used:      s.used,
+			Budget:    t.budget,
+			Limit:     s.limit,
+			Remaining: s.remaining,
+			ResetAt:   s.resetAt,
+			Exhausted: t.exhaustedLocked(s, now),
+		})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Provider < usages[j].Provider
+	})
+
+	return usages
+}
+
+func (t *Tracker) record(ctx context.Context, provider string, names int64, resp *http.Response) {
+	const op = "enrichment.quota.Tracker.record"
+
+	now := time.Now()
+
+	t.mu.Lock()
+	s := t.stateLocked(provider, now)
+	if resp.StatusCode == http.StatusOK {
+		s.used += names
+	}
+	if v, ok := headerInt(resp.Header, headerLimit); ok {
+		s.limit = &v
+	}
+	if v, ok := headerInt(resp.Header, headerRemaining); ok {
+		s.remaining = &v
+	}
+	if v, ok := headerInt(resp.Header, headerReset); ok {
+		resetAt := now.Add(time.Duration(v) * time.Second)
+		s.resetAt = &resetAt
+	}
+	usage := &models.QuotaUsage{
+		Provider:  provider,
+		Day:       s.day,
+		Limit:     s.limit,
+		Remaining: s.remaining,
+		ResetAt:   s.resetAt,
+	}
+	if resp.StatusCode == http.StatusOK {
+		usage.Used = names
+	}
+	t.mu.Unlock()
+
+	// запрос к источнику уже выполнен, поэтому расход сохраняется и после отмены запроса клиента
+	if err := t.store.AddQuotaUsage(context.WithoutCancel(ctx), usage); err != nil {
+		t.log.Error("Failed to save enrichment quota usage",
+			slog.String("op", op), slog.String("provider", provider), slog.String("error", err.Error()))
+	}
+}
+
+// stateLocked возвращает счетчики источника, начиная новые сутки при их смене; вызывается под t.mu
+func (t *Tracker) stateLocked(provider string, now time.Time) *state {
+	day := now.UTC().Truncate(24 * time.Hour)
+
+	s, ok := t.providers[provider]
+	if !ok {
+		s = &state{day: day}
+		t.providers[provider] = s
+	}
+	if !s.day.Equal(day) {
+		*s = state{day: day}
+	}
+	if s.resetAt != nil && now.After(*s.resetAt) {
+		s.remaining = nil
+		s.resetAt = nil
+	}
+
+	return s
+}
+
+// exhaustedLocked вызывается под t.mu
+func (t *Tracker) exhaustedLocked(s *state, now time.Time) bool {
+	if t.budget > 0 && s.used >= t.budget {
+		return true
+	}
+
+	return s.remaining != nil && *s.remaining <= t.minRemaining && (s.resetAt == nil || now.Before(*s.resetAt))
+}
+
+type transport struct {
+	tracker  *Tracker
+	provider string
+	next     http.RoundTripper
+}
+
+func (tr *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := tr.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tr.tracker.record(req.Context(), tr.provider, countNames(req), resp)
+
+	return resp, nil
+}
+
+// countNames число имен в запросе: публичные API списывают квоту за каждое имя пакета
+func countNames(req *http.Request) int64 {
+	query := req.URL.Query()
+	if names := query[batchNameParam]; len(names) > 0 {
+		return int64(len(names))
+	}
+	if query.Has(nameParam) {
+		return 1
+	}
+
+	return 0
+}
+
+func headerInt(h http.Header, key string) (int64, bool) {
+	v := h.Get(key)
+	if v == "" {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,116 @@
+package reenrich
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/Gustcat/people-info-service/internal/lib/audit"
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+type Store interface {
+	GetByID(ctx context.Context, id int64) (*models.FullPerson, error)
+	ManualAttributes(ctx context.Context, id int64) ([]string, error)
+	ApplyEnrichment(ctx context.Context, id int64, update *models.EnrichmentUpdate) (*models.FullPerson, error)
+}
+
+type Enricher interface {
+	Enrich(ctx context.Context, person *models.Person) (*models.EnrichmentPerson, error)
+}
+
+// Service повторно обогащает сохраненные профили.
+// Пустые значения источников не затирают сохраненные данные.
+type Service struct {
+	log      *slog.Logger
+	store    Store
+	enricher Enricher
+}
+
+func New(log *slog.Logger, store Store, enricher Enricher) *Service {
+	return &Service{
+		log:      log,
+		store:    store,
+		enricher: enricher,
+	}
+}
+
+// Reenrich обогащает профиль заново. Если overwriteManual не задан,
+// атрибуты, измененные вручную, остаются без изменений и попадают в Skipped.
+func (s *Service) Reenrich(ctx context.Context, id int64, overwriteManual bool) (*models.EnrichmentReport, error) {
+	const op = "enrichment.reenrich.Service.Reenrich"
+	log := s.log.With(slog.String("op", op), slog.Int64("id", id))
+
+	person, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	enriched, err := s.enricher.Enrich(ctx, &person.Person)
+	if err != nil {
+		log.Warn("Person enriched partially", slog.String("error", err.Error()))
+	}
+
+	manual := make([]string, 0)
+	if !overwriteManual {
+		manual, err = s.store.ManualAttributes(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	report := &models.EnrichmentReport{
+		ID:      id,
+		Changes: make([]models.EnrichmentChange, 0),
+	}
+	update := &models.EnrichmentUpdate{}
+
+	update.Age = diff(report, manual, "age", person.Age, enriched.Age)
+	update.Gender = diff(report, manual, "gender", person.Gender, enriched.Gender)
+	update.Nationality = diff(report, manual, "nationality", person.Nationality, enriched.Nationality)
+
+	// происхождение и кандидаты обновляются при каждом ответе источника, даже если значение
+	// не изменилось; отброшенное источником значение не заменяет происхождение сохраненного
+	stored := map[string]bool{"age": person.Age != nil, "gender": person.Gender != nil, "nationality": person.Nationality != nil}
+	fetched := map[string]bool{"age": enriched.Age != nil, "gender": enriched.Gender != nil, "nationality": enriched.Nationality != nil}
+	update.Provenance = make(map[string]*models.Provenance, len(enriched.Provenance))
+	for field, p := range enriched.Provenance {
+		if slices.Contains(manual, field) || stored[field] && !fetched[field] {
+			continue
+		}
+		update.Provenance[field] = p
+	}
+	if !slices.Contains(manual, "nationality") {
+		update.NationalityCandidates = enriched.NationalityCandidates
+	}
+
+	if len(report.Changes) == 0 && len(update.Provenance) == 0 && update.NationalityCandidates == nil {
+		log.Debug("Nothing to change", slog.Any("skipped", report.Skipped))
+		return report, nil
+	}
+
+	if _, err := s.store.ApplyEnrichment(audit.WithSource(ctx, models.HistorySourceEnrichment), id, update); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("Person re-enriched", slog.Any("changes", report.Changes))
+
+	return report, nil
+}
+
+// diff сравнивает сохраненное и новое значение атрибута и возвращает значение для обновления
+func diff[T comparable](report *models.EnrichmentReport, manual []string, field string, old, new *T) *T {
+	if new == nil || (old != nil && *old == *new) {
+		return nil
+	}
+
+	if slices.Contains(manual, field) {
+		report.Skipped = append(report.Skipped, field)
+		return nil
+	}
+
+	report.Changes = append(report.Changes, models.EnrichmentChange{Field: field, Old: old, New: new})
+
+	return new
+}
@@ -0,0 +1,154 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/Gustcat/people-info-service/internal/lib/translit"
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+// Registry хранит источники обогащения по атрибутам и опрашивает их параллельно
+type Registry struct {
+	log *slog.Logger
+
+	mu         sync.RWMutex
+	enrichers  map[Attribute]Enricher
+	thresholds map[Attribute]Threshold
+}
+
+func NewRegistry(log *slog.Logger, enrichers ...Enricher) *Registry {
+	r := &Registry{
+		log:        log,
+		enrichers:  make(map[Attribute]Enricher, len(enrichers)),
+		thresholds: make(map[Attribute]Threshold),
+	}
+	for _, e := range enrichers {
+		r.Register(e)
+	}
+
+	return r
+}
+
+// Register добавляет источник, заменяя ранее зарегистрированный для того же атрибута
+func (r *Registry) Register(e Enricher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.enrichers[e.Attribute()] = e
+}
+
+// SetThreshold задает минимальную достоверность, ниже которой значение атрибута отбрасывается
+func (r *Registry) SetThreshold(attr Attribute, t Threshold) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.thresholds[attr] = t
+}
+
+// Enrich дополняет ФИО данными всех зарегистрированных источников.
+// Возраст и пол уточняются для страны: подсказки клиента или, если ее нет,
+// национальности, определенной до их запроса.
+// Кириллическое имя запрашивается в транслитерации: публичные источники знают в основном латинское написание.
+// Ошибка источника не прерывает обогащение: соответствующий атрибут остается пустым,
+// а ошибки всех источников возвращаются вместе с частично обогащенным профилем.
+func (r *Registry) Enrich(ctx context.Context, person *models.Person) (*models.EnrichmentPerson, error) {
+	const op = "enrichment.Registry.Enrich"
+	log := r.log.With(slog.String("op", op))
+
+	enrichPerson := &models.EnrichmentPerson{
+		Person: *person,
+	}
+
+	r.mu.RLock()
+	var general, localized []Enricher
+	for attr, e := range r.enrichers {
+		if localizable(attr) {
+			localized = append(localized, e)
+		} else {
+			general = append(general, e)
+		}
+	}
+	thresholds := maps.Clone(r.thresholds)
+	r.mu.RUnlock()
+
+	name := translit.Latin(person.Name)
+
+	var countryID string
+	if person.CountryHint != nil {
+		countryID = strings.ToUpper(*person.CountryHint)
+	}
+
+	if countryID != "" || len(localized) == 0 {
+		errs := r.run(ctx, log, append(general, localized...), name, countryID, thresholds, enrichPerson)
+		return enrichPerson, errors.Join(errs...)
+	}
+
+	errs := r.run(ctx, log, general, name, "", thresholds, enrichPerson)
+	if enrichPerson.Nationality != nil {
+		countryID = *enrichPerson.Nationality
+	}
+	errs = append(errs, r.run(ctx, log, localized, name, countryID, thresholds, enrichPerson)...)
+
+	return enrichPerson, errors.Join(errs...)
+}
+
+// run параллельно опрашивает источники и дополняет профиль их результатами
+func (r *Registry) run(ctx context.Context, log *slog.Logger, enrichers []Enricher, name, countryID string,
+	thresholds map[Attribute]Threshold, enrichPerson *models.EnrichmentPerson) []error {
+	wg := &sync.WaitGroup{}
+	mu := &sync.Mutex{}
+	var errs []error
+
+	for _, e := range enrichers {
+		wg.Add(1)
+		go func(e Enricher) {
+			defer wg.Done()
+
+			attr := e.Attribute()
+			q := Query{Name: name}
+			if localizable(attr) {
+				q.CountryID = countryID
+			}
+
+			res, err := e.Enrich(ctx, q)
+			if err != nil {
+				log.Error("Failed to enrich attribute",
+					slog.String("attribute", string(attr)), slog.String("error", err.Error()))
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", attr, err))
+				mu.Unlock()
+				return
+			}
+			log.Debug("Receive attribute", slog.String("attribute", string(attr)),
+				slog.String("country_id", q.CountryID), slog.Any("result", res))
+
+			var rejectReason string
+			if res.value(attr) != nil {
+				rejectReason = thresholds[attr].check(res)
+			}
+			if rejectReason != "" {
+				log.Info("Attribute rejected for low confidence",
+					slog.String("attribute", string(attr)), slog.String("reason", rejectReason), slog.Any("result", res))
+			}
+
+			mu.Lock()
+			res.apply(attr, enrichPerson, rejectReason)
+			mu.Unlock()
+		}(e)
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+// localizable сообщает, уточняется ли атрибут для страны
+func localizable(attr Attribute) bool {
+	return attr == AttributeAge || attr == AttributeGender
+}
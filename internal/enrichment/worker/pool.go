@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/lib/audit"
+	"github.com/Gustcat/people-info-service/internal/models"
+	"github.com/Gustcat/people-info-service/internal/repository"
+)
+
+type Store interface {
+	GetByID(ctx context.Context, id int64) (*models.FullPerson, error)
+	ClaimEnrichmentJobs(ctx context.Context, limit uint64, lease time.Duration) ([]*models.EnrichmentJob, error)
+	CompleteEnrichmentJob(ctx context.Context, job *models.EnrichmentJob, person *models.EnrichmentPerson, status models.EnrichmentStatus) error
+	RetryEnrichmentJob(ctx context.Context, job *models.EnrichmentJob, lastErr string, runAfter time.Time) error
+}
+
+type Enricher interface {
+	Enrich(ctx context.Context, person *models.Person) (*models.EnrichmentPerson, error)
+}
+
+// Budget сообщает, исчерпана ли квота источников обогащения
+type Budget interface {
+	Exhausted() bool
+}
+
+type Options struct {
+	Workers      int
+	BatchSize    uint64
+	PollInterval time.Duration
+	Lease        time.Duration
+	MaxAttempts  int
+	RetryDelay   time.Duration
+}
+
+// Pool обрабатывает задачи фонового обогащения из таблицы enrichment_job
+type Pool struct {
+	log      *slog.Logger
+	store    Store
+	enricher Enricher
+	opts     Options
+	budget   Budget
+}
+
+func New(log *slog.Logger, store Store, enricher Enricher, opts Options) *Pool {
+	return &Pool{
+		log:      log,
+		store:    store,
+		enricher: enricher,
+		opts:     opts,
+	}
+}
+
+// SetBudget откладывает задачи, пока квота источников обогащения исчерпана
+func (p *Pool) SetBudget(b Budget) {
+	p.budget = b
+}
+
+// Run опрашивает очередь задач и раздает их обработчикам до отмены ctx
+func (p *Pool) Run(ctx context.Context) {
+	const op = "enrichment.worker.Pool.Run"
+	log := p.log.With(slog.String("op", op))
+
+	jobs := make(chan *models.EnrichmentJob)
+	wg := &sync.WaitGroup{}
+
+	for range p.opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				p.process(ctx, job)
+			}
+		}()
+	}
+
+	log.Info("Enrichment workers started", slog.Int("workers", p.opts.Workers))
+	defer func() {
+		close(jobs)
+		wg.Wait()
+		log.Info("Enrichment workers stopped")
+	}()
+
+	ticker := time.NewTicker(p.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if p.budget != nil && p.budget.Exhausted() {
+			log.Debug("Enrichment quota exhausted, jobs are postponed")
+			select {
+			case <-ticker.C:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		claimed, err := p.store.ClaimEnrichmentJobs(ctx, p.opts.BatchSize, p.opts.Lease)
+		if err != nil && ctx.Err() == nil {
+			log.Error("Failed to claim enrichment jobs", slog.String("error", err.Error()))
+		}
+
+		for _, job := range claimed {
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// полная пачка означает, что в очереди могут остаться задачи
+		if uint64(len(claimed)) == p.opts.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Pool) process(ctx context.Context, job *models.EnrichmentJob) {
+	const op = "enrichment.worker.Pool.process"
+	log := p.log.With(slog.String("op", op), slog.Int64("job_id", job.ID), slog.Int64("person_id", job.PersonID))
+
+	person, err := p.store.GetByID(ctx, job.PersonID)
+	if errors.Is(err, repository.ErrPersonNotFound) {
+		log.Warn("Person for enrichment job not found")
+		return
+	}
+	if err != nil {
+		log.Error("Failed to get person", slog.String("error", err.Error()))
+		p.retry(ctx, log, job, err)
+		return
+	}
+
+	person.CountryHint = job.CountryHint
+	enriched, err := p.enricher.Enrich(ctx, &person.Person)
+	status := models.EnrichmentStatusDone
+	if err != nil {
+		if job.Attempts < p.opts.MaxAttempts {
+			log.Warn("Enrichment failed, will retry",
+				slog.Int("attempt", job.Attempts), slog.String("error", err.Error()))
+			p.retry(ctx, log, job, err)
+			return
+		}
+		log.Error("Enrichment failed, attempts exhausted",
+			slog.Int("attempt", job.Attempts), slog.String("error", err.Error()))
+		status = models.EnrichmentStatusFailed
+	}
+
+	if err := p.store.CompleteEnrichmentJob(audit.WithSource(ctx, models.HistorySourceEnrichment), job, enriched, status); err != nil {
+		log.Error("Failed to save enrichment", slog.String("error", err.Error()))
+		return
+	}
+
+	log.Info("Person enriched", slog.String("status", string(status)))
+}
+
+func (p *Pool) retry(ctx context.Context, log *slog.Logger, job *models.EnrichmentJob, cause error) {
+	runAfter := time.Now().Add(p.opts.RetryDelay * time.Duration(job.Attempts))
+	if err := p.store.RetryEnrichmentJob(ctx, job, cause.Error(), runAfter); err != nil {
+		log.Error("Failed to reschedule enrichment job", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/Gustcat/people-info-service/internal/lib/breaker"
+	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/go-chi/render"
+)
+
+type BreakerSnapshoter interface {
+	Snapshots() []breaker.Snapshot
+}
+
+// Breakers возвращает состояние предохранителей источников обогащения
+//
+// @Summary      Состояние предохранителей источников обогащения
+// @Description  Для каждого источника возвращает состояние предохранителя (closed, open, half-open) и число неудач подряд
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  swagger.BreakersResponse
+// @Router       /admin/enrichment/breakers [get]
+func Breakers(ctx context.Context, log *slog.Logger, snapshoter BreakerSnapshoter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.Breakers"
+		log := log.With(slog.String("op", op))
+
+		snapshots := snapshoter.Snapshots()
+		log.Debug("Enrichment breakers", slog.Any("breakers", snapshots))
+
+		render.JSON(w, r, response.OK[[]breaker.Snapshot](&snapshots))
+	}
+}
@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/Gustcat/people-info-service/internal/enrichment/cache"
+	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/go-chi/render"
+)
+
+type CacheStatser interface {
+	Stats() *cache.Stats
+}
+
+// CacheStats возвращает статистику кэша обогащения
+//
+// @Summary      Статистика кэша обогащения
+// @Description  Возвращает количество попаданий в кэш в памяти и в БД, промахов и текущий размер кэша в памяти
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  swagger.CacheStatsResponse
+// @Router       /admin/enrichment/cache [get]
+func CacheStats(ctx context.Context, log *slog.Logger, statser CacheStatser) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.CacheStats"
+		log := log.With(slog.String("op", op))
+
+		stats := statser.Stats()
+		log.Debug("Enrichment cache stats", slog.Any("stats", stats))
+
+		render.JSON(w, r, response.OK[cache.Stats](stats))
+	}
+}
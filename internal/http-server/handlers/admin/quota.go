@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/Gustcat/people-info-service/internal/enrichment/quota"
+	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/go-chi/render"
+)
+
+type QuotaUsager interface {
+	Usage() []quota.Usage
+}
+
+// Quota возвращает расход квоты источников обогащения за текущие сутки
+//
+// @Summary      Квота источников обогащения
+// @Description  Для каждого источника возвращает число запрошенных за сутки (UTC) имен, бюджет,
+// @Description  лимит и остаток по заголовкам X-Rate-Limit-* и признак исчерпания бюджета
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  swagger.QuotaResponse
+// @Router       /admin/enrichment/quota [get]
+func Quota(ctx context.Context, log *slog.Logger, usager QuotaUsager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.Quota"
+		log := log.With(slog.String("op", op))
+
+		usage := usager.Usage()
+		log.Debug("Enrichment quota", slog.Any("quota", usage))
+
+		render.JSON(w, r, response.OK[[]quota.Usage](&usage))
+	}
+}
@@ -2,16 +2,13 @@ package persons
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
-	"sync"
 
 	"github.com/Gustcat/people-info-service/internal/lib/response"
-	"github.com/Gustcat/people-info-service/internal/lib/urlbuilder"
 	"github.com/Gustcat/people-info-service/internal/lib/validation"
 	"github.com/Gustcat/people-info-service/internal/models"
 	"github.com/Gustcat/people-info-service/internal/repository"
@@ -19,64 +16,59 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
-const (
-	urlForAge         = "https://api.agify.io/"
-	urlForGender      = "https://api.genderize.io/"
-	urlForNationality = "https://api.nationalize.io/"
-	nameParam         = "name"
-)
-
 type Creator interface {
 	Create(ctx context.Context, person *models.EnrichmentPerson) (int64, error)
+	PendingCreator
+}
+
+type PendingCreator interface {
+	CreatePending(ctx context.Context, person *models.Person) (int64, error)
+}
+
+type Enricher interface {
+	Enrich(ctx context.Context, person *models.Person) (*models.EnrichmentPerson, error)
+}
+
+type Budget interface {
+	Exhausted() bool
 }
 
 // Create создает профиль человека
 //
 // @Summary      Создать профиль человека
-// @Description  Вводится ФИО, данные обогащаются возрастом, национальностью и полом, возращается ID созданной записи
+// @Description  Вводится ФИО, данные обогащаются возрастом, национальностью и полом, возращается ID созданной записи.
+// @Description  При асинхронном обогащении или исчерпанной квоте публичных API запись сохраняется сразу
+// @Description  со статусом pending и возвращается 202.
 // @Tags         persons
 // @Accept       json
 // @Produce      json
 // @Param        input body models.Person true "ФИО"
 // @Success      201  {object}  swagger.IdResponse
+// @Success      202  {object}  swagger.IdResponse
 // @Failure      400  {object}  swagger.ErrorResponse
 // @Failure      500  {object}  swagger.ErrorResponse
 // @Router       /persons/ [post]
-func Create(ctx context.Context, log *slog.Logger, creator Creator) http.HandlerFunc {
+func Create(ctx context.Context, log *slog.Logger, creator Creator, enricher Enricher, budget Budget) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.Create"
 		log := log.With(slog.String("op", op))
 
-		var person models.Person
-
-		log.Debug("Receive create request")
-		err := render.DecodeJSON(r.Body, &person)
-		if errors.Is(err, io.EOF) {
-			log.Error("Bad request: empty request")
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, response.Error("empty request"))
-			return
-		}
-
-		if err != nil {
-			log.Error("Failed to parse request", slog.String("error", err.Error()))
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, response.Error("failed to parse request"))
+		person, isDecoded := decodePerson(w, r, log)
+		if !isDecoded {
 			return
 		}
-		log.Debug("Parsed create successfully", slog.Any("person", person))
 
-		if err := validator.New().Struct(person); err != nil {
-			validateErr := err.(validator.ValidationErrors)
-			errMsg := validation.ErrorMessage(validateErr)
-			log.Error("Validation failure", slog.String("error", errMsg))
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, response.Error(errMsg))
+		if budget.Exhausted() {
+			log.Warn("Enrichment quota exhausted, enrichment is postponed")
+			createPending(w, r, log, creator, person)
 			return
 		}
 
 		log.Debug("Try to enrich person information")
-		enrichPerson := enrichPerson(&person, log)
+		enrichPerson, err := enricher.Enrich(r.Context(), person)
+		if err != nil {
+			log.Warn("Person enriched partially", slog.String("error", err.Error()))
+		}
 		log.Debug("Enrich person successfully", slog.Any("enrich", enrichPerson))
 
 		id, err := creator.Create(r.Context(), enrichPerson)
@@ -102,127 +94,75 @@ func Create(ctx context.Context, log *slog.Logger, creator Creator) http.Handler
 	}
 }
 
-func enrichPerson(person *models.Person, log *slog.Logger) *models.EnrichmentPerson {
-	wg := &sync.WaitGroup{}
-	mu := &sync.Mutex{}
-
-	enrichPerson := &models.EnrichmentPerson{
-		Person: *person,
-	}
-
-	wg.Add(3)
-
-	go func() {
-		defer wg.Done()
-		fulUrlForAge, err := urlbuilder.BuildWithQueryParams(urlForAge, map[string]string{nameParam: person.Name})
-		if err != nil {
-			log.Error("Failed to parse url for age", slog.String("error", err.Error()))
-			return
-		}
-		log.Debug("URL for age", slog.String("url", fulUrlForAge))
+// CreateAsync создает профиль человека без ожидания обогащения.
+// Запись сохраняется со статусом обогащения pending, атрибуты заполняет фоновый обработчик.
+// Используется вместо Create при включенном асинхронном обогащении, описание API см. у Create.
+func CreateAsync(ctx context.Context, log *slog.Logger, creator PendingCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.CreateAsync"
+		log := log.With(slog.String("op", op))
 
-		resp, err := http.Get(fulUrlForAge)
-		if err != nil {
-			log.Error("Failed to fetch url for age", slog.String("error", err.Error()))
+		person, isDecoded := decodePerson(w, r, log)
+		if !isDecoded {
 			return
 		}
-		defer resp.Body.Close()
-
-		var data struct {
-			Age *int64 `json:"age"`
-		}
 
-		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-			log.Error("Failed to decode response body", slog.String("error", err.Error()))
-			return
-		}
-		if data.Age == nil {
-			log.Debug("Receive age", slog.Int64("age", *data.Age))
-		} else {
-			log.Debug("Receive empty age")
-		}
-
-		mu.Lock()
-		enrichPerson.Age = data.Age
-		mu.Unlock()
-	}()
-
-	go func() {
-		defer wg.Done()
-
-		fulUrlForGender, err := urlbuilder.BuildWithQueryParams(urlForGender, map[string]string{nameParam: person.Name})
-		if err != nil {
-			log.Error("Failed to parse url for gender", slog.String("error", err.Error()))
-		}
-		log.Debug("URL for gender", slog.String("url", fulUrlForGender))
-
-		resp, err := http.Get(fulUrlForGender)
-		if err != nil {
-			log.Error("Failed to fetch url for gender", slog.String("error", err.Error()))
-		}
-		defer resp.Body.Close()
-
-		var data struct {
-			Gender      *models.Gender `json:"gender"`
-			Probability float64        `json:"probability"`
-		}
-
-		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-			log.Error("Failed to decode response body", slog.String("error", err.Error()))
-		}
-		log.Debug("Receive answer", slog.Any("data", data))
-		if data.Probability < 0.7 {
-			data.Gender = nil
-		}
-
-		mu.Lock()
-		enrichPerson.Gender = data.Gender
-		mu.Unlock()
-	}()
-
-	go func() {
-		defer wg.Done()
-
-		fullUrlNationality, err := urlbuilder.BuildWithQueryParams(urlForNationality, map[string]string{nameParam: person.Name})
-		if err != nil {
-			log.Error("Failed to parse url for nationality", slog.String("error", err.Error()))
-		}
-		log.Debug("URL for nationality", slog.String("url", fullUrlNationality))
-
-		resp, err := http.Get(fullUrlNationality)
-		if err != nil {
-			log.Error("Failed to fetch url for nationality", slog.String("error", err.Error()))
-		}
-		defer resp.Body.Close()
+		createPending(w, r, log, creator, person)
+	}
+}
 
-		var data struct {
-			Country []struct {
-				CountryID   string  `json:"country_id"`
-				Probability float64 `json:"probability"`
-			} `json:"country"`
-		}
+// createPending сохраняет профиль без обогащения и ставит его в очередь фонового обогащения
+func createPending(w http.ResponseWriter, r *http.Request, log *slog.Logger, creator PendingCreator, person *models.Person) {
+	id, err := creator.CreatePending(r.Context(), person)
+	if errors.Is(err, repository.ErrPersonExists) {
+		log.Error("Get error", slog.String("error", err.Error()))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error(fmt.Sprintf(
+			"Person with name %s %s already exists", person.Name, person.Surname)))
+		return
+	}
 
-		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-			log.Error("Failed to decode response body", slog.String("error", err.Error()))
-		}
-		log.Debug("Get countries", slog.Int("count", len(data.Country)))
+	if err != nil {
+		log.Error("Failed to add person", slog.String("error", err.Error()))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to add person"))
+		return
+	}
 
-		var nationality *string
-		probability := 0.0
+	log.Info("Person created, enrichment is pending", slog.Int64("id", id))
+	createResp := &models.Identifier{ID: id}
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, response.OK[models.Identifier](createResp))
+}
 
-		for _, version := range data.Country {
-			if version.Probability > probability {
-				nationality = &version.CountryID
-				probability = version.Probability
-			}
-		}
+func decodePerson(w http.ResponseWriter, r *http.Request, log *slog.Logger) (*models.Person, bool) {
+	var person models.Person
 
-		mu.Lock()
-		enrichPerson.Nationality = nationality
-		mu.Unlock()
-	}()
+	log.Debug("Receive create request")
+	err := render.DecodeJSON(r.Body, &person)
+	if errors.Is(err, io.EOF) {
+		log.Error("Bad request: empty request")
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("empty request"))
+		return nil, false
+	}
 
-	wg.Wait()
+	if err != nil {
+		log.Error("Failed to parse request", slog.String("error", err.Error()))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to parse request"))
+		return nil, false
+	}
+	log.Debug("Parsed create successfully", slog.Any("person", person))
+
+	if err := validator.New().Struct(person); err != nil {
+		validateErr := err.(validator.ValidationErrors)
+		errMsg := validation.ErrorMessage(validateErr)
+		log.Error("Validation failure", slog.String("error", errMsg))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error(errMsg))
+		return nil, false
+	}
 
-	return enrichPerson
+	return &person, true
 }
@@ -2,28 +2,37 @@ package persons
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 
 	"github.com/Gustcat/people-info-service/internal/lib/params"
 	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/Gustcat/people-info-service/internal/repository"
 	"github.com/go-chi/render"
 )
 
 type Deleter interface {
-	Delete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id int64, version *int64) error
 }
 
 // Delete удаляет профиль человека по ID
 //
 // @Summary      Удаляет профиль человека
-// @Description  Удаляет профиль человека
+// @Description  Помечает профиль удаленным: он исчезает из выборок и может быть восстановлен
+// @Description  через POST /persons/{id}/restore до окончательной очистки по истечении срока хранения.
+// @Description  С заголовком If-Match профиль удаляется, только если ETag совпадает с текущей версией
 // @Tags         persons
 // @Accept       json
 // @Produce      json
 // @Param        id  path      int  true  "Идентификатор профиля человека"
+// @Param        If-Match  header  string  false  "ETag версии профиля, полученный в GET /persons/{id}"
 // @Success      200  {object}  swagger.EmptyResponse
 // @Failure      400  {object}  swagger.ErrorResponse
+// @Failure      404  {object}  swagger.ErrorResponse
+// @Failure      412  {object}  swagger.ErrorResponse
+// @Failure      428  {object}  swagger.ErrorResponse
 // @Failure      500  {object}  swagger.ErrorResponse
 // @Router       /persons/{id} [delete]
 func Delete(ctx context.Context, log *slog.Logger, deleter Deleter) http.HandlerFunc {
@@ -36,7 +45,27 @@ func Delete(ctx context.Context, log *slog.Logger, deleter Deleter) http.Handler
 			return
 		}
 
-		if err := deleter.Delete(r.Context(), id); err != nil {
+		version, isParse := parseIfMatch(w, r, log)
+		if !isParse {
+			return
+		}
+
+		err := deleter.Delete(r.Context(), id, version)
+		if errors.Is(err, repository.ErrPersonNotFound) {
+			log.Error("Failed to delete person", slog.String("error", err.Error()))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error(fmt.Sprintf("Person with id=%d not found", id)))
+			return
+		}
+
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			log.Warn("Person was modified concurrently", slog.Int64("id", id))
+			render.Status(r, http.StatusPreconditionFailed)
+			render.JSON(w, r, response.Error(errVersionMismatch))
+			return
+		}
+
+		if err != nil {
 			log.Error("Failed to delete person", slog.String("error", err.Error()))
 			render.Status(r, http.StatusInternalServerError)
 			render.JSON(w, r, response.Error("failed to delete person"))
@@ -44,7 +73,6 @@ func Delete(ctx context.Context, log *slog.Logger, deleter Deleter) http.Handler
 		}
 
 		log.Info("Person deleted", slog.Int64("id", id))
-		// можно со статусом 204 обработать вариант, когда совершается попытка удалить несуществующий объект
 		render.JSON(w, r, response.OK[struct{}](nil))
 	}
 }
@@ -0,0 +1,135 @@
+package persons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Gustcat/people-info-service/internal/lib/filter"
+	"github.com/Gustcat/people-info-service/internal/lib/params"
+	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/Gustcat/people-info-service/internal/models"
+	"github.com/Gustcat/people-info-service/internal/repository"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+)
+
+// defaultReenrichLimit ограничивает массовое обогащение, если limit не задан
+const defaultReenrichLimit uint64 = 100
+
+type Reenricher interface {
+	Reenrich(ctx context.Context, id int64, overwriteManual bool) (*models.EnrichmentReport, error)
+}
+
+type EnrichmentQueue interface {
+	EnqueueEnrichment(ctx context.Context, filter *filter.PersonFilter, overwriteManual bool) ([]int64, error)
+}
+
+type reenrichParams struct {
+	OverwriteManual bool `schema:"overwrite_manual"`
+}
+
+type reenrichManyParams struct {
+	filter.PersonFilter
+	reenrichParams
+}
+
+// Reenrich повторно обогащает профиль человека по ID
+//
+// @Summary      Повторно обогатить профиль человека
+// @Description  Заново запрашивает возраст, пол и национальность и возвращает изменившиеся поля.
+// @Description  Поля, измененные вручную, не перезаписываются без overwrite_manual=true
+// @Tags         persons
+// @Produce      json
+// @Param        id                path   int   true   "Идентификатор профиля человека"
+// @Param        overwrite_manual  query  bool  false  "Перезаписывать поля, измененные вручную"
+// @Success      200  {object}  swagger.EnrichmentReportResponse
+// @Failure      400  {object}  swagger.ErrorResponse
+// @Failure      404  {object}  swagger.ErrorResponse
+// @Failure      500  {object}  swagger.ErrorResponse
+// @Router       /persons/{id}/enrich [post]
+func Reenrich(ctx context.Context, log *slog.Logger, reenricher Reenricher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.Reenrich"
+		log := log.With(slog.String("op", op))
+
+		id, isParse := params.ParseIDParam(w, r, log)
+		if !isParse {
+			return
+		}
+
+		var p reenrichParams
+		if err := schema.NewDecoder().Decode(&p, r.URL.Query()); err != nil {
+			log.Error("Bad request", slog.String("error", err.Error()))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(fmt.Sprintf("invalid query-parameter: %s", err.Error())))
+			return
+		}
+
+		report, err := reenricher.Reenrich(r.Context(), id, p.OverwriteManual)
+		if errors.Is(err, repository.ErrPersonNotFound) {
+			log.Error("Failed to get person", slog.String("error", err.Error()))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error(fmt.Sprintf("Person with id=%d not found", id)))
+			return
+		}
+
+		if err != nil {
+			log.Error("Failed to re-enrich person", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to re-enrich person"))
+			return
+		}
+
+		render.JSON(w, r, response.OK[models.EnrichmentReport](report))
+	}
+}
+
+// ReenrichMany ставит профили, подходящие под фильтр, в очередь повторного обогащения
+//
+// @Summary      Повторно обогатить профили людей
+// @Description  Ставит профили, подходящие под фильтр (как у списка профилей), в очередь фонового обогащения и возвращает их идентификаторы.
+// @Description  Изменения появляются в профилях по мере обработки очереди; изменившиеся поля каждого профиля возвращает
+// @Description  GET /persons/{id}/history в записях с source enrichment. Без limit в очередь ставится не более 100 профилей
+// @Tags         persons
+// @Produce      json
+// @Param        filter            query  filter.PersonFilter  false  "Фильтрация и пагинация"
+// @Param        overwrite_manual  query  bool                 false  "Перезаписывать поля, измененные вручную"
+// @Success      202  {object}  swagger.IDsResponse
+// @Failure      400  {object}  swagger.ErrorResponse
+// @Failure      500  {object}  swagger.ErrorResponse
+// @Router       /persons/enrich [post]
+func ReenrichMany(ctx context.Context, log *slog.Logger, queue EnrichmentQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.ReenrichMany"
+		log := log.With(slog.String("op", op))
+
+		var p reenrichManyParams
+		if err := schema.NewDecoder().Decode(&p, r.URL.Query()); err != nil {
+			log.Error("Bad request", slog.String("error", err.Error()))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(fmt.Sprintf("invalid query-parameter: %s", err.Error())))
+			return
+		}
+
+		if p.Limit == nil {
+			limit := defaultReenrichLimit
+			p.Limit = &limit
+		}
+
+		log.Debug("Enqueue re-enrichment by filter", slog.Any("filter", p.PersonFilter))
+		ids, err := queue.EnqueueEnrichment(r.Context(), &p.PersonFilter, p.OverwriteManual)
+		if err != nil {
+			log.Error("Failed to enqueue re-enrichment", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to re-enrich persons"))
+			return
+		}
+
+		log.Info("Persons queued for re-enrichment", slog.Int("count", len(ids)))
+		render.Status(r, http.StatusAccepted)
+		render.JSON(w, r, response.OK[[]int64](&ids))
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/Gustcat/people-info-service/internal/lib/etag"
 	"github.com/Gustcat/people-info-service/internal/lib/params"
 	"github.com/Gustcat/people-info-service/internal/lib/response"
 	"github.com/Gustcat/people-info-service/internal/models"
@@ -16,6 +17,7 @@ import (
 
 type Getter interface {
 	GetByID(ctx context.Context, id int64) (*models.FullPerson, error)
+	LoadProvenance(ctx context.Context, persons ...*models.FullPerson) error
 }
 
 // GetByID возвращает профиль человека по ID
@@ -25,8 +27,10 @@ type Getter interface {
 // @Tags         persons
 // @Accept       json
 // @Produce      json
-// @Param        id  path      int  true  "Идентификатор профиля человека"
+// @Param        id       path      int     true   "Идентификатор профиля человека"
+// @Param        include  query     string  false  "Дополнительные данные: provenance - происхождение атрибутов обогащения"
 // @Success      200  {object}  swagger.FullPersonResponse
+// @Header       200  {string}  ETag  "Версия профиля для заголовка If-Match"
 // @Failure      400  {object}  swagger.ErrorResponse
 // @Failure      404  {object}  swagger.ErrorResponse
 // @Failure      500  {object}  swagger.ErrorResponse
@@ -57,6 +61,16 @@ func GetByID(ctx context.Context, log *slog.Logger, getter Getter) http.HandlerF
 			return
 		}
 
+		if params.Includes(r.URL.Query()[params.IncludeParam], params.IncludeProvenance) {
+			if err := getter.LoadProvenance(ctx, person); err != nil {
+				log.Error("Failed to load provenance", slog.String("error", err.Error()))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to get person"))
+				return
+			}
+		}
+
+		w.Header().Set(etag.HeaderETag, etag.Format(person.Version))
 		render.JSON(w, r, response.OK[models.FullPerson](person))
 	}
 }
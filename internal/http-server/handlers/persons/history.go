@@ -0,0 +1,107 @@
+package persons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Gustcat/people-info-service/internal/lib/params"
+	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/Gustcat/people-info-service/internal/lib/urlbuilder"
+	"github.com/Gustcat/people-info-service/internal/models"
+	"github.com/Gustcat/people-info-service/internal/repository"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+)
+
+type HistoryGetter interface {
+	GetByID(ctx context.Context, id int64) (*models.FullPerson, error)
+	History(ctx context.Context, personID int64, limit, offset uint64) ([]*models.HistoryEntry, uint64, error)
+}
+
+type historyParams struct {
+	Limit  *uint64 `schema:"limit"`
+	Offset *uint64 `schema:"offset"`
+}
+
+// History возвращает журнал изменений профиля человека по ID
+//
+// @Summary      Журнал изменений профиля
+// @Description  Возвращает создание, изменения и удаление профиля со старыми и новыми значениями полей,
+// @Description  автором (заголовок X-Actor), временем и каналом изменения (api, import, enrichment)
+// @Tags         persons
+// @Produce      json
+// @Param        id  path      int  true  "Идентификатор профиля человека"
+// @Param        limit  query  int  false  "Число записей"
+// @Param        offset  query  int  false  "Смещение"
+// @Success      200  {object}  swagger.HistoryResponse
+// @Failure      400  {object}  swagger.ErrorResponse
+// @Failure      404  {object}  swagger.ErrorResponse
+// @Failure      500  {object}  swagger.ErrorResponse
+// @Router       /persons/{id}/history [get]
+func History(ctx context.Context, log *slog.Logger, getter HistoryGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.History"
+		log := log.With(slog.String("op", op))
+
+		id, isParse := params.ParseIDParam(w, r, log)
+		if !isParse {
+			return
+		}
+
+		var p historyParams
+		if err := schema.NewDecoder().Decode(&p, r.URL.Query()); err != nil {
+			log.Error("Bad request", slog.String("error", err.Error()))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(fmt.Sprintf("invalid query-parameter: %s", err.Error())))
+			return
+		}
+
+		limit := response.DefaultLimit
+		offset := response.DefaultOffset
+		if p.Limit != nil {
+			limit = *p.Limit
+		}
+		if p.Offset != nil {
+			offset = *p.Offset
+		}
+
+		entries, total, err := getter.History(ctx, id, limit, offset)
+		if err != nil {
+			log.Error("Failed to get history", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get history"))
+			return
+		}
+
+		// у удаленного профиля остается журнал, поэтому 404 только при пустой истории
+		if total == 0 {
+			_, err = getter.GetByID(ctx, id)
+			if errors.Is(err, repository.ErrPersonNotFound) {
+				log.Error("Failed to get person", slog.String("error", err.Error()))
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error(fmt.Sprintf("Person with id=%d not found", id)))
+				return
+			}
+
+			if err != nil {
+				log.Error("Error calling GetByID", slog.String("error", err.Error()))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to get person"))
+				return
+			}
+		}
+
+		pagination, err := response.NewPagination(limit, offset, total, urlbuilder.RequestURL(r))
+		if err != nil {
+			log.Error("Failed to create pagination", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to create pagination"))
+			return
+		}
+
+		render.JSON(w, r, response.OKWithPagination[[]*models.HistoryEntry](&entries, pagination))
+	}
+}
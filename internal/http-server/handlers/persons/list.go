@@ -6,7 +6,9 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/Gustcat/people-info-service/internal/lib/cursor"
 	"github.com/Gustcat/people-info-service/internal/lib/filter"
+	"github.com/Gustcat/people-info-service/internal/lib/params"
 	"github.com/Gustcat/people-info-service/internal/lib/response"
 	"github.com/Gustcat/people-info-service/internal/lib/urlbuilder"
 	"github.com/Gustcat/people-info-service/internal/models"
@@ -16,21 +18,34 @@ import (
 
 type Lister interface {
 	List(ctx context.Context, filter *filter.PersonFilter) ([]*models.FullPerson, uint64, error)
+	LoadProvenance(ctx context.Context, persons ...*models.FullPerson) error
+}
+
+type listParams struct {
+	filter.PersonFilter
+	Include []string `schema:"include"`
 }
 
 // List возвращает профили людей
 //
 // @Summary      Возвращает профили людей
-// @Description  Возращает профили всех людей с возможностью фильтрации по значению полей и пагинации
+// @Description  Возращает профили всех людей с возможностью фильтрации по значению полей, сортировки и пагинации.
+// @Description  Параметр sort принимает поля через запятую, "-" перед полем задает убывание: sort=-age,surname.
+// @Description  Профили с равными значениями полей сортировки упорядочиваются по id.
+// @Description  Вместо offset можно передать cursor - значение next_cursor или prev_cursor из предыдущего ответа
+// @Description  с той же сортировкой; count=false отключает подсчет total.
+// @Description  Параметр q принимает выражение с AND, OR, NOT, скобками, сравнениями, IN и IS [NOT] NULL;
+// @Description  ошибка разбора возвращается с позицией ошибочной лексемы
 // @Tags         persons
 // @Accept       json
 // @Produce      json
 // @Param        filter query filter.PersonFilter  false "Фильтрация и пагинация"
+// @Param        include query string  false "Дополнительные данные: provenance - происхождение атрибутов обогащения"
 // @Success      200  {object}  swagger.PersonsWithPaginationResponse
 // @Failure      400  {object}  swagger.ErrorResponse
 // @Failure      500  {object}  swagger.ErrorResponse
 // @Router       /persons/ [get]
-func List(ctx context.Context, log *slog.Logger, lister Lister) http.HandlerFunc {
+func List(ctx context.Context, log *slog.Logger, lister Lister, cursors *cursor.Signer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.List"
 		log := log.With(slog.String("op", op))
@@ -38,8 +53,8 @@ func List(ctx context.Context, log *slog.Logger, lister Lister) http.HandlerFunc
 		decoder := schema.NewDecoder()
 
 		log.Debug("Receive list request")
-		var personFilter filter.PersonFilter
-		err := decoder.Decode(&personFilter, r.URL.Query())
+		var p listParams
+		err := decoder.Decode(&p, r.URL.Query())
 		if err != nil {
 			log.Error("Bad request", slog.String("error", err.Error()))
 			render.Status(r, http.StatusBadRequest)
@@ -47,6 +62,37 @@ func List(ctx context.Context, log *slog.Logger, lister Lister) http.HandlerFunc
 			return
 		}
 
+		personFilter := p.PersonFilter
+		if personFilter.Cursor != nil {
+			if personFilter.Offset != nil {
+				log.Error("Bad request: cursor with offset")
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error("cursor and offset cannot be used together"))
+				return
+			}
+
+			personFilter.Keyset, err = keyset(cursors, *personFilter.Cursor, personFilter.Sort)
+			if err != nil {
+				log.Error("Bad request", slog.String("error", err.Error()))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error(err.Error()))
+				return
+			}
+		}
+
+		offset := response.DefaultOffset
+		limit := response.DefaultLimit
+		if personFilter.Limit != nil {
+			limit = *personFilter.Limit
+		}
+		if personFilter.Offset != nil {
+			offset = *personFilter.Offset
+		}
+
+		// лишний профиль показывает, есть ли страница дальше
+		fetch := limit + 1
+		personFilter.Limit = &fetch
+
 		log.Debug("Get persons from DB by filter", slog.Any("filter", personFilter))
 		persons, total, err := lister.List(ctx, &personFilter)
 		if err != nil {
@@ -56,22 +102,122 @@ func List(ctx context.Context, log *slog.Logger, lister Lister) http.HandlerFunc
 			return
 		}
 
-		url := urlbuilder.BaseURL(r)
-		offset := response.DefaultOffset
-		limit := response.DefaultLimit
-		if personFilter.Limit != nil {
-			limit = *personFilter.Limit
+		backward := personFilter.Keyset != nil && personFilter.Keyset.Backward
+		more := uint64(len(persons)) > limit
+		if more && backward {
+			persons = persons[1:]
+		} else if more {
+			persons = persons[:limit]
 		}
-		if personFilter.Offset != nil {
-			offset = *personFilter.Offset
+
+		if params.Includes(p.Include, params.IncludeProvenance) {
+			if err := lister.LoadProvenance(ctx, persons...); err != nil {
+				log.Error("Failed to load provenance", slog.String("error", err.Error()))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to list persons"))
+				return
+			}
+		}
+
+		hasNext, hasPrev := more, offset > 0
+		if personFilter.Keyset != nil {
+			hasNext, hasPrev = more || backward, more || !backward
+		}
+
+		var next, prev *string
+		if hasNext && len(persons) > 0 {
+			next, err = encodeCursor(cursors, personFilter.Sort, persons[len(persons)-1], false)
+		}
+		if err == nil && hasPrev && len(persons) > 0 && personFilter.Keyset != nil {
+			prev, err = encodeCursor(cursors, personFilter.Sort, persons[0], true)
+		}
+		if err != nil {
+			log.Error("Failed to encode cursor", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to create pagination"))
+			return
+		}
+
+		var totalPtr *uint64
+		if personFilter.Count == nil || *personFilter.Count {
+			totalPtr = &total
+		}
+
+		url := urlbuilder.RequestURL(r)
+		var pagination *response.Pagination
+		if personFilter.Keyset != nil {
+			pagination, err = response.NewCursorPagination(limit, totalPtr, url, next, prev)
+		} else {
+			// без подсчета ссылка на следующую страницу строится по лишнему профилю
+			if totalPtr == nil {
+				total = offset + uint64(len(persons))
+				if more {
+					total++
+				}
+			}
+			pagination, err = response.NewPagination(limit, offset, total, url)
+			if pagination != nil {
+				pagination.Total = totalPtr
+				pagination.NextCursor = next
+			}
 		}
-		pagination, err := response.NewPagination(limit, offset, total, url)
 		if err != nil {
 			log.Error("Failed to create pagination", slog.String("error", err.Error()))
 			render.Status(r, http.StatusInternalServerError)
 			render.JSON(w, r, response.Error("failed to create pagination"))
+			return
 		}
 
 		render.JSON(w, r, response.OKWithPagination[[]*models.FullPerson](&persons, pagination))
 	}
 }
+
+// keyset восстанавливает границу выдачи из курсора, выданного для той же сортировки
+func keyset(cursors *cursor.Signer, token string, sort *filter.Sort) (*filter.Keyset, error) {
+	c, err := cursors.Decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Sort != sortKey(sort) {
+		return nil, fmt.Errorf("cursor was issued for sort %q", c.Sort)
+	}
+
+	fields := sort.WithTieBreak()
+	if len(c.Values) != len(fields) {
+		return nil, cursor.ErrInvalid
+	}
+
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		if values[i], err = filter.ParseSortValue(f.Field, c.Values[i]); err != nil {
+			return nil, cursor.ErrInvalid
+		}
+	}
+
+	return &filter.Keyset{Values: values, Backward: c.Backward}, nil
+}
+
+// encodeCursor курсор страницы после профиля p или, если backward, до него
+func encodeCursor(cursors *cursor.Signer, sort *filter.Sort, p *models.FullPerson, backward bool) (*string, error) {
+	fields := sort.WithTieBreak()
+	values := make([]*string, len(fields))
+	for i, f := range fields {
+		values[i] = filter.SortValue(p, f.Field)
+	}
+
+	token, err := cursors.Encode(&cursor.Cursor{Sort: sortKey(sort), Values: values, Backward: backward})
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func sortKey(sort *filter.Sort) string {
+	if sort == nil {
+		return ""
+	}
+
+	return sort.String()
+}
@@ -0,0 +1,69 @@
+package persons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Gustcat/people-info-service/internal/lib/params"
+	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/Gustcat/people-info-service/internal/models"
+	"github.com/Gustcat/people-info-service/internal/repository"
+	"github.com/go-chi/render"
+)
+
+type NationalitiesGetter interface {
+	GetByID(ctx context.Context, id int64) (*models.FullPerson, error)
+	NationalityCandidates(ctx context.Context, id int64) ([]models.NationalityCandidate, error)
+}
+
+// Nationalities возвращает кандидаты национальности человека по ID
+//
+// @Summary      Кандидаты национальности
+// @Description  Возвращает все национальности, предложенные источником обогащения, по убыванию вероятности
+// @Tags         persons
+// @Produce      json
+// @Param        id  path      int  true  "Идентификатор профиля человека"
+// @Success      200  {object}  swagger.NationalityCandidatesResponse
+// @Failure      400  {object}  swagger.ErrorResponse
+// @Failure      404  {object}  swagger.ErrorResponse
+// @Failure      500  {object}  swagger.ErrorResponse
+// @Router       /persons/{id}/nationalities [get]
+func Nationalities(ctx context.Context, log *slog.Logger, getter NationalitiesGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.Nationalities"
+		log := log.With(slog.String("op", op))
+
+		id, isParse := params.ParseIDParam(w, r, log)
+		if !isParse {
+			return
+		}
+
+		_, err := getter.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrPersonNotFound) {
+			log.Error("Failed to get person", slog.String("error", err.Error()))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error(fmt.Sprintf("Person with id=%d not found", id)))
+			return
+		}
+
+		if err != nil {
+			log.Error("Error calling GetByID", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get person"))
+			return
+		}
+
+		candidates, err := getter.NationalityCandidates(ctx, id)
+		if err != nil {
+			log.Error("Failed to get nationality candidates", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get nationality candidates"))
+			return
+		}
+
+		render.JSON(w, r, response.OK[[]models.NationalityCandidate](&candidates))
+	}
+}
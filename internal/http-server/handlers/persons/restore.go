@@ -0,0 +1,72 @@
+package persons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Gustcat/people-info-service/internal/lib/etag"
+	"github.com/Gustcat/people-info-service/internal/lib/params"
+	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/Gustcat/people-info-service/internal/models"
+	"github.com/Gustcat/people-info-service/internal/repository"
+	"github.com/go-chi/render"
+)
+
+type Restorer interface {
+	Restore(ctx context.Context, id int64) (*models.FullPerson, error)
+}
+
+// Restore восстанавливает удаленный профиль человека по ID
+//
+// @Summary      Восстанавливает профиль человека
+// @Description  Возвращает удаленный профиль в выборки, если он еще не очищен по истечении срока хранения
+// @Tags         persons
+// @Produce      json
+// @Param        id  path      int  true  "Идентификатор профиля человека"
+// @Success      200  {object}  swagger.FullPersonResponse
+// @Header       200  {string}  ETag  "Версия восстановленного профиля"
+// @Failure      400  {object}  swagger.ErrorResponse
+// @Failure      404  {object}  swagger.ErrorResponse
+// @Failure      500  {object}  swagger.ErrorResponse
+// @Router       /persons/{id}/restore [post]
+func Restore(ctx context.Context, log *slog.Logger, restorer Restorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.Restore"
+		log := log.With(slog.String("op", op))
+
+		id, isParse := params.ParseIDParam(w, r, log)
+		if !isParse {
+			return
+		}
+
+		person, err := restorer.Restore(r.Context(), id)
+		if errors.Is(err, repository.ErrPersonNotFound) {
+			log.Error("Failed to restore person", slog.String("error", err.Error()))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error(fmt.Sprintf("Deleted person with id=%d not found", id)))
+			return
+		}
+
+		if errors.Is(err, repository.ErrPersonExists) {
+			log.Error("Failed to restore person", slog.String("error", err.Error()))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(fmt.Sprintf(
+				"Person with the same name as deleted person id=%d already exists", id)))
+			return
+		}
+
+		if err != nil {
+			log.Error("Failed to restore person", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to restore person"))
+			return
+		}
+
+		log.Info("Person restored", slog.Int64("id", id))
+		w.Header().Set(etag.HeaderETag, etag.Format(person.Version))
+		render.JSON(w, r, response.OK[models.FullPerson](person))
+	}
+}
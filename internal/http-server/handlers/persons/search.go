@@ -0,0 +1,93 @@
+package persons
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Gustcat/people-info-service/internal/lib/filter"
+	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/Gustcat/people-info-service/internal/lib/urlbuilder"
+	"github.com/Gustcat/people-info-service/internal/models"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+)
+
+type Searcher interface {
+	Search(ctx context.Context, filter *filter.SearchFilter) ([]*models.ScoredPerson, uint64, error)
+}
+
+// Search ищет профили людей по имени, фамилии и отчеству
+//
+// @Summary      Поиск профилей по имени
+// @Description  Находит профили по имени, фамилии и отчеству с учетом опечаток и без учета регистра.
+// @Description  Профили упорядочены по убыванию оценки совпадения score от 0 до 1;
+// @Description  min_score задает наименьшую оценку, по умолчанию SEARCH_MIN_SCORE
+// @Tags         persons
+// @Produce      json
+// @Param        filter query filter.SearchFilter  true "Поиск и пагинация"
+// @Success      200  {object}  swagger.ScoredPersonsWithPaginationResponse
+// @Failure      400  {object}  swagger.ErrorResponse
+// @Failure      500  {object}  swagger.ErrorResponse
+// @Router       /persons/search [get]
+func Search(ctx context.Context, log *slog.Logger, searcher Searcher, minScore float64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.Search"
+		log := log.With(slog.String("op", op))
+
+		var f filter.SearchFilter
+		if err := schema.NewDecoder().Decode(&f, r.URL.Query()); err != nil {
+			log.Error("Bad request", slog.String("error", err.Error()))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(fmt.Sprintf("invalid query-parameter: %s", err.Error())))
+			return
+		}
+
+		f.Q = strings.TrimSpace(f.Q)
+		if f.Q == "" {
+			log.Error("Bad request: empty search query")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("query-parameter q is required"))
+			return
+		}
+
+		if f.MinScore == nil {
+			f.MinScore = &minScore
+		} else if *f.MinScore < 0 || *f.MinScore > 1 {
+			log.Error("Bad request: min_score out of range")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("min_score must be between 0 and 1"))
+			return
+		}
+
+		limit := response.DefaultLimit
+		offset := response.DefaultOffset
+		if f.Limit != nil {
+			limit = *f.Limit
+		}
+		if f.Offset != nil {
+			offset = *f.Offset
+		}
+		f.Limit, f.Offset = &limit, &offset
+
+		persons, total, err := searcher.Search(r.Context(), &f)
+		if err != nil {
+			log.Error("Failed to search persons", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to search persons"))
+			return
+		}
+
+		pagination, err := response.NewPagination(limit, offset, total, urlbuilder.RequestURL(r))
+		if err != nil {
+			log.Error("Failed to create pagination", slog.String("error", err.Error()))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to create pagination"))
+			return
+		}
+
+		render.JSON(w, r, response.OKWithPagination[[]*models.ScoredPerson](&persons, pagination))
+	}
+}
@@ -8,30 +8,37 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/Gustcat/people-info-service/internal/lib/etag"
 	"github.com/Gustcat/people-info-service/internal/lib/params"
 	"github.com/Gustcat/people-info-service/internal/lib/response"
 	"github.com/Gustcat/people-info-service/internal/lib/validation"
 	"github.com/Gustcat/people-info-service/internal/models"
+	"github.com/Gustcat/people-info-service/internal/repository"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 )
 
 type Updater interface {
-	Update(ctx context.Context, id int64, personUpdate *models.PersonUpdate) (*models.FullPerson, error)
+	Update(ctx context.Context, id int64, personUpdate *models.PersonUpdate, version *int64) (*models.FullPerson, error)
 }
 
 // Update редактирует профиль человека по ID
 //
 // @Summary      Редактирует профиль человека
-// @Description  У записи с определенным ID редактирует поля
+// @Description  У записи с определенным ID редактирует поля.
+// @Description  С заголовком If-Match изменение выполняется, только если ETag совпадает с текущей версией профиля
 // @Tags         persons
 // @Accept       json
 // @Produce      json
 // @Param        id  path      int  true  "Идентификатор профиля человека"
 // @Param        input body models.PersonUpdate true "Редактируемые поля"
+// @Param        If-Match  header  string  false  "ETag версии профиля, полученный в GET /persons/{id}"
 // @Success      200  {object}  swagger.FullPersonResponse
+// @Header       200  {string}  ETag  "Новая версия профиля"
 // @Failure      400  {object}  swagger.ErrorResponse
 // @Failure      404  {object}  swagger.ErrorResponse
+// @Failure      412  {object}  swagger.ErrorResponse
+// @Failure      428  {object}  swagger.ErrorResponse
 // @Failure      500  {object}  swagger.ErrorResponse
 // @Router       /persons/{id} [patch]
 func Update(ctx context.Context, log *slog.Logger, updater Updater) http.HandlerFunc {
@@ -44,6 +51,11 @@ func Update(ctx context.Context, log *slog.Logger, updater Updater) http.Handler
 			return
 		}
 
+		version, isParse := parseIfMatch(w, r, log)
+		if !isParse {
+			return
+		}
+
 		var personUpdate *models.PersonUpdate
 		log.Debug("Receive update request")
 		err := validation.DecodeStrictJSON(r, &personUpdate)
@@ -71,7 +83,21 @@ func Update(ctx context.Context, log *slog.Logger, updater Updater) http.Handler
 		}
 
 		log.Debug("Try to update person in DB")
-		person, err := updater.Update(ctx, id, personUpdate)
+		person, err := updater.Update(r.Context(), id, personUpdate, version)
+		if errors.Is(err, repository.ErrPersonNotFound) {
+			log.Error("Failed to update person", slog.String("error", err.Error()))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error(fmt.Sprintf("Person with id=%d not found", id)))
+			return
+		}
+
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			log.Warn("Person was modified concurrently", slog.Int64("id", id))
+			render.Status(r, http.StatusPreconditionFailed)
+			render.JSON(w, r, response.Error(errVersionMismatch))
+			return
+		}
+
 		if err != nil {
 			log.Error("Failed to update person", slog.String("error", err.Error()))
 			render.Status(r, http.StatusInternalServerError)
@@ -80,6 +106,7 @@ func Update(ctx context.Context, log *slog.Logger, updater Updater) http.Handler
 		}
 
 		log.Info("Person updated", slog.Int64("id", id))
+		w.Header().Set(etag.HeaderETag, etag.Format(person.Version))
 		render.JSON(w, r, response.OK[models.FullPerson](person))
 	})
 }
@@ -92,3 +119,18 @@ func isEmptyPersonUpdate(p *models.PersonUpdate) bool {
 		p.Gender == nil &&
 		p.Nationality == nil
 }
+
+const errVersionMismatch = "person was modified: If-Match does not match current ETag"
+
+// parseIfMatch возвращает версию профиля из заголовка If-Match; nil означает изменение без проверки версии
+func parseIfMatch(w http.ResponseWriter, r *http.Request, log *slog.Logger) (*int64, bool) {
+	version, _, err := etag.IfMatch(r)
+	if err != nil {
+		log.Warn("Unknown If-Match", slog.String("if_match", r.Header.Get(etag.HeaderIfMatch)))
+		render.Status(r, http.StatusPreconditionFailed)
+		render.JSON(w, r, response.Error(errVersionMismatch))
+		return nil, false
+	}
+
+	return version, true
+}
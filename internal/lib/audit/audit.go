@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+const (
+	// HeaderActor заголовок с автором изменения, например логином сотрудника
+	HeaderActor = "X-Actor"
+	// HeaderSource заголовок, которым клиент помечает изменения, сделанные импортом
+	HeaderSource = "X-Change-Source"
+)
+
+type actorKey struct{}
+
+type sourceKey struct{}
+
+// WithActor сохраняет в ctx автора изменения
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor возвращает автора изменения или nil, если он не известен
+func Actor(ctx context.Context) *string {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	if !ok || actor == "" {
+		return nil
+	}
+
+	return &actor
+}
+
+// WithSource сохраняет в ctx канал изменения
+func WithSource(ctx context.Context, source models.HistorySource) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// Source возвращает канал изменения; по умолчанию изменение считается сделанным через API
+func Source(ctx context.Context) models.HistorySource {
+	if source, ok := ctx.Value(sourceKey{}).(models.HistorySource); ok {
+		return source
+	}
+
+	return models.HistorySourceAPI
+}
+
+// Middleware переносит автора и канал изменения из заголовков запроса в его контекст.
+// Клиент может пометить изменение только как импорт: обогащение записывается сервисом.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if actor := r.Header.Get(HeaderActor); actor != "" {
+			ctx = WithActor(ctx, actor)
+		}
+		if models.HistorySource(r.Header.Get(HeaderSource)) == models.HistorySourceImport {
+			ctx = WithSource(ctx, models.HistorySourceImport)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,117 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	type step struct {
+		// action allow, success, failure, abort или wait
+		action    string
+		wantErr   error
+		wantState State
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after threshold failures in a row",
+			steps: []step{
+				{action: "allow", wantState: StateClosed},
+				{action: "failure", wantState: StateClosed},
+				{action: "allow", wantState: StateClosed},
+				{action: "failure", wantState: StateOpen},
+				{action: "allow", wantErr: ErrOpen, wantState: StateOpen},
+			},
+		},
+		{
+			name: "success resets failures",
+			steps: []step{
+				{action: "failure", wantState: StateClosed},
+				{action: "success", wantState: StateClosed},
+				{action: "failure", wantState: StateClosed},
+			},
+		},
+		{
+			name: "closes after successful probe",
+			steps: []step{
+				{action: "failure"},
+				{action: "failure", wantState: StateOpen},
+				{action: "wait", wantState: StateOpen},
+				{action: "allow", wantState: StateHalfOpen},
+				{action: "allow", wantErr: ErrOpen, wantState: StateHalfOpen},
+				{action: "success", wantState: StateClosed},
+				{action: "allow", wantState: StateClosed},
+			},
+		},
+		{
+			name: "reopens after failed probe",
+			steps: []step{
+				{action: "failure"},
+				{action: "failure", wantState: StateOpen},
+				{action: "wait", wantState: StateOpen},
+				{action: "allow", wantState: StateHalfOpen},
+				{action: "failure", wantState: StateOpen},
+				{action: "allow", wantErr: ErrOpen, wantState: StateOpen},
+			},
+		},
+		{
+			name: "aborted probe lets another through",
+			steps: []step{
+				{action: "failure"},
+				{action: "failure", wantState: StateOpen},
+				{action: "wait", wantState: StateOpen},
+				{action: "allow", wantState: StateHalfOpen},
+				{action: "abort", wantState: StateHalfOpen},
+				{action: "allow", wantState: StateHalfOpen},
+			},
+		},
+	}
+
+	const openTimeout = 20 * time.Millisecond
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New("test", 2, openTimeout)
+			for i, s := range tt.steps {
+				var err error
+				switch s.action {
+				case "allow":
+					err = b.Allow()
+				case "success":
+					b.Success()
+				case "failure":
+					b.Failure()
+				case "abort":
+					b.Abort()
+				case "wait":
+					time.Sleep(openTimeout)
+				}
+
+				if !errors.Is(err, s.wantErr) {
+					t.Fatalf("step %d %s: error = %v, want %v", i, s.action, err, s.wantErr)
+				}
+				if s.wantState == "" {
+					continue
+				}
+				if state := b.Snapshot().State; state != s.wantState {
+					t.Fatalf("step %d %s: state = %s, want %s", i, s.action, state, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshotOpenedAt(t *testing.T) {
+	b := New("agify", 1, time.Minute)
+	if s := b.Snapshot(); s.Name != "agify" || s.OpenedAt != nil {
+		t.Errorf("closed snapshot = %+v, want name agify without opened_at", s)
+	}
+
+	b.Failure()
+	if s := b.Snapshot(); s.OpenedAt == nil || s.Failures != 1 {
+		t.Errorf("open snapshot = %+v, want opened_at and 1 failure", s)
+	}
+}
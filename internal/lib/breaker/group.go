@@ -0,0 +1,57 @@
+package breaker
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Group набор именованных предохранителей с общими настройками
+type Group struct {
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+func NewGroup(threshold int, openTimeout time.Duration) *Group {
+	return &Group{
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		breakers:    make(map[string]*Breaker),
+	}
+}
+
+// Get возвращает предохранитель по имени, создавая его при первом обращении
+func (g *Group) Get(name string) *Breaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	b, ok := g.breakers[name]
+	if !ok {
+		b = New(name, g.threshold, g.openTimeout)
+		g.breakers[name] = b
+	}
+
+	return b
+}
+
+func (g *Group) Snapshots() []Snapshot {
+	g.mu.Lock()
+	breakers := make([]*Breaker, 0, len(g.breakers))
+	for _, b := range g.breakers {
+		breakers = append(breakers, b)
+	}
+	g.mu.Unlock()
+
+	snapshots := make([]Snapshot, 0, len(breakers))
+	for _, b := range breakers {
+		snapshots = append(snapshots, b.Snapshot())
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+
+	return snapshots
+}
@@ -0,0 +1,79 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrInvalid курсор поврежден или подписан другим ключом
+var ErrInvalid = errors.New("invalid cursor")
+
+// Cursor позиция в упорядоченной выдаче: значения полей сортировки граничной записи.
+// Backward означает выборку записей до границы, то есть предыдущей страницы.
+type Cursor struct {
+	Sort     string    `json:"s"`
+	Values   []*string `json:"v"`
+	Backward bool      `json:"b,omitempty"`
+}
+
+// Signer кодирует курсоры в непрозрачные строки и проверяет их подпись,
+// чтобы клиент не мог подменить значения границы
+type Signer struct {
+	key []byte
+}
+
+func NewSigner(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+// Encode возвращает подписанный курсор
+func (s *Signer) Encode(c *Cursor) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return encode(payload) + "." + encode(s.sign(payload)), nil
+}
+
+// Decode проверяет подпись курсора и возвращает его
+func (s *Signer) Decode(token string) (*Cursor, error) {
+	rawPayload, rawSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(rawPayload)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(rawSignature)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	if !hmac.Equal(signature, s.sign(payload)) {
+		return nil, ErrInvalid
+	}
+
+	var c Cursor
+	if err = json.Unmarshal(payload, &c); err != nil {
+		return nil, ErrInvalid
+	}
+
+	return &c, nil
+}
+
+func (s *Signer) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
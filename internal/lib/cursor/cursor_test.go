@@ -0,0 +1,80 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	age, id := "30", "7"
+	tests := []struct {
+		name string
+		c    *Cursor
+	}{
+		{name: "forward", c: &Cursor{Sort: "age:desc", Values: []*string{&age, &id}}},
+		{name: "backward", c: &Cursor{Sort: "age:desc", Values: []*string{&age, &id}, Backward: true}},
+		{name: "null value", c: &Cursor{Sort: "age", Values: []*string{nil, &id}}},
+	}
+	s := NewSigner([]byte("secret"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := s.Encode(tt.c)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+
+			got, err := s.Decode(token)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.c) {
+				t.Errorf("Decode = %+v, want %+v", got, tt.c)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsTamperedCursor(t *testing.T) {
+	id := "7"
+	s := NewSigner([]byte("secret"))
+	token, err := s.Encode(&Cursor{Sort: "id", Values: []*string{&id}})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+
+	forged, err := NewSigner([]byte("other")).Encode(&Cursor{Sort: "id", Values: []*string{&id}})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	_, forgedSignature, _ := strings.Cut(forged, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty", token: ""},
+		{name: "no signature", token: payload},
+		{name: "changed payload", token: base64.RawURLEncoding.EncodeToString([]byte(`{"s":"id","v":["8"]}`)) + "." + signature},
+		{name: "other key", token: payload + "." + forgedSignature},
+		{name: "truncated signature", token: payload + "." + signature[:len(signature)-2]},
+		{name: "invalid base64", token: payload + ".!!!"},
+		{name: "signed garbage", token: signedGarbage(s)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Decode(tt.token); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Decode error = %v, want %v", err, ErrInvalid)
+			}
+		})
+	}
+}
+
+// signedGarbage возвращает правильно подписанный курсор, который не является JSON
+func signedGarbage(s *Signer) string {
+	payload := []byte("not json")
+	return encode(payload) + "." + encode(s.sign(payload))
+}
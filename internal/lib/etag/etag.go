@@ -0,0 +1,68 @@
+package etag
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Gustcat/people-info-service/internal/lib/response"
+	"github.com/go-chi/render"
+)
+
+const (
+	HeaderETag    = "ETag"
+	HeaderIfMatch = "If-Match"
+
+	anyETag = "*"
+)
+
+// ErrUnknownETag заголовок If-Match не содержит ETag, выданный сервисом
+var ErrUnknownETag = errors.New("If-Match does not contain a known ETag")
+
+// Format строгий ETag версии записи
+func Format(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// IfMatch возвращает версию из заголовка If-Match. Версия равна nil, если заголовок
+// отсутствует или равен "*"; present сообщает, передан ли заголовок. Слабые ETag,
+// списки и чужие значения не могут совпасть с текущей версией и дают ErrUnknownETag.
+func IfMatch(r *http.Request) (version *int64, present bool, err error) {
+	value := strings.TrimSpace(r.Header.Get(HeaderIfMatch))
+	if value == "" {
+		return nil, false, nil
+	}
+	if value == anyETag {
+		return nil, true, nil
+	}
+
+	unquoted, ok := strings.CutPrefix(value, `"`)
+	if !ok {
+		return nil, true, ErrUnknownETag
+	}
+	unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	if !ok {
+		return nil, true, ErrUnknownETag
+	}
+
+	v, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return nil, true, ErrUnknownETag
+	}
+
+	return &v, true, nil
+}
+
+// Require отвечает 428 Precondition Required на запросы без заголовка If-Match
+func Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderIfMatch) == "" {
+			render.Status(r, http.StatusPreconditionRequired)
+			render.JSON(w, r, response.Error("If-Match header is required"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,15 +1,71 @@
 package filter
 
-import "github.com/Gustcat/people-info-service/internal/models"
+import (
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/lib/query"
+)
 
 type PersonFilter struct {
-	Name        *string        `schema:"name"`
-	Surname     *string        `schema:"surname"`
-	Patronymic  *string        `schema:"patronymic"`
-	Gender      *models.Gender `schema:"gender"`
-	AgeMin      *int64         `schema:"age_min"`
-	AgeMax      *int64         `schema:"age_max"`
-	Nationality *string        `schema:"nationality"`
-	Limit       *uint64        `schema:"limit"`
-	Offset      *uint64        `schema:"offset"`
+	// Name, Surname, Patronymic, Gender и Nationality принимают список значений через запятую,
+	// "!" в начале списка исключает профили с этими значениями
+	Name        *Values       `schema:"name" swaggertype:"string" example:"Ivan,Oleg"`
+	Surname     *Values       `schema:"surname" swaggertype:"string"`
+	Patronymic  *Values       `schema:"patronymic" swaggertype:"string"`
+	Gender      *GenderValues `schema:"gender" swaggertype:"string" example:"male"`
+	AgeMin      *int64        `schema:"age_min"`
+	AgeMax      *int64        `schema:"age_max"`
+	Nationality *Values       `schema:"nationality" swaggertype:"string" example:"RU,UA,KZ"`
+
+	// NamePrefix, SurnamePrefix и PatronymicPrefix отбирают профили, поле которых начинается
+	// с одного из значений списка; "!" в начале списка исключает их
+	NamePrefix       *Values `schema:"name_prefix" swaggertype:"string" example:"Iv"`
+	SurnamePrefix    *Values `schema:"surname_prefix" swaggertype:"string"`
+	PatronymicPrefix *Values `schema:"patronymic_prefix" swaggertype:"string"`
+	// IgnoreCase сравнивает имя, фамилию и отчество и их начало без учета регистра
+	IgnoreCase *bool `schema:"ignore_case"`
+
+	// IsNull и NotNull отбирают профили без значения или со значением полей из списка
+	IsNull  *Fields `schema:"is_null" swaggertype:"string" example:"gender"`
+	NotNull *Fields `schema:"not_null" swaggertype:"string" example:"nationality"`
+
+	// CandidateCountry отбирает людей, у которых среди кандидатов национальности есть страна
+	// с вероятностью не ниже CandidateProbabilityMin
+	CandidateCountry        *string  `schema:"candidate_country"`
+	CandidateProbabilityMin *float64 `schema:"candidate_probability_min"`
+
+	// CreatedAfter, UpdatedAfter и парные им Before отбирают профили, созданные или измененные
+	// строго после (до) момента в формате RFC 3339, например для инкрементальной синхронизации
+	CreatedAfter  *time.Time `schema:"created_after"`
+	CreatedBefore *time.Time `schema:"created_before"`
+	UpdatedAfter  *time.Time `schema:"updated_after"`
+	UpdatedBefore *time.Time `schema:"updated_before"`
+
+	// Q выражение с логическими связками, например
+	// (nationality = "RU" OR nationality = "BY") AND age >= 30 AND NOT gender IS NULL
+	Q *query.Expr `schema:"q" swaggertype:"string" example:"age >= 30 AND NOT gender IS NULL"`
+
+	// IncludeDeleted добавляет к выборке удаленные, но еще не очищенные профили
+	IncludeDeleted *bool `schema:"include_deleted"`
+
+	// Sort порядок выдачи, по умолчанию по id
+	Sort *Sort `schema:"sort" swaggertype:"string" example:"-age,surname"`
+
+	Limit  *uint64 `schema:"limit"`
+	Offset *uint64 `schema:"offset"`
+
+	// Cursor непрозрачный курсор next_cursor или prev_cursor из предыдущего ответа; заменяет Offset
+	Cursor *string `schema:"cursor"`
+	// Count=false отключает подсчет общего числа профилей
+	Count *bool `schema:"count"`
+
+	// Keyset граница выдачи, восстановленная из Cursor
+	Keyset *Keyset `schema:"-" swaggerignore:"true"`
+}
+
+// Keyset граница выдачи: профили выбираются после нее или, если Backward, до нее.
+// Values содержит значения полей Sort.WithTieBreak() граничного профиля.
+type Keyset struct {
+	Values   []any
+	Backward bool
 }
@@ -0,0 +1,78 @@
+package filter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+// NullableFields поля профиля, которые можно проверить на отсутствие значения
+var NullableFields = []string{
+	"patronymic",
+	"age",
+	"gender",
+	"nationality",
+}
+
+// Values значения фильтра через запятую: профиль подходит, если поле равно одному из них,
+// например nationality=RU,UA,KZ. "!" в начале отрицает фильтр: nationality=!RU отбирает
+// профили с другой или неизвестной национальностью.
+type Values struct {
+	Items []string
+	Not   bool
+}
+
+func (v *Values) UnmarshalText(text []byte) error {
+	s := string(text)
+	s, v.Not = strings.CutPrefix(s, "!")
+
+	v.Items = v.Items[:0]
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return fmt.Errorf("empty value in %q", text)
+		}
+		v.Items = append(v.Items, item)
+	}
+
+	return nil
+}
+
+// GenderValues значения фильтра gender: male или female
+type GenderValues struct {
+	Values
+}
+
+func (v *GenderValues) UnmarshalText(text []byte) error {
+	if err := v.Values.UnmarshalText(text); err != nil {
+		return err
+	}
+
+	for _, item := range v.Items {
+		if !models.IsValidGender(models.Gender(item)) {
+			return fmt.Errorf("unknown gender %q, allowed: %s, %s", item, models.GenderMale, models.GenderFemale)
+		}
+	}
+
+	return nil
+}
+
+// Fields поля профиля через запятую для проверок is_null и not_null, например is_null=gender,age
+type Fields struct {
+	Items []string
+}
+
+func (f *Fields) UnmarshalText(text []byte) error {
+	f.Items = f.Items[:0]
+	for _, field := range strings.Split(string(text), ",") {
+		field = strings.TrimSpace(field)
+		if !slices.Contains(NullableFields, field) {
+			return fmt.Errorf("unknown nullable field %q, allowed: %s", field, strings.Join(NullableFields, ", "))
+		}
+		f.Items = append(f.Items, field)
+	}
+
+	return nil
+}
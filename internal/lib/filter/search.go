@@ -0,0 +1,12 @@
+package filter
+
+// SearchFilter параметры поиска профилей по имени, фамилии и отчеству
+type SearchFilter struct {
+	// Q искомый текст: части имени в любом порядке, допускаются опечатки и другой регистр
+	Q string `schema:"q" example:"ivanov"`
+	// MinScore наименьшая оценка совпадения от 0 до 1
+	MinScore *float64 `schema:"min_score" example:"0.3"`
+
+	Limit  *uint64 `schema:"limit"`
+	Offset *uint64 `schema:"offset"`
+}
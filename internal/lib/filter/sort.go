@@ -0,0 +1,147 @@
+package filter
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+// SortFields поля профиля, по которым разрешена сортировка
+var SortFields = []string{
+	"id",
+	"name",
+	"surname",
+	"patronymic",
+	"age",
+	"gender",
+	"nationality",
+	"created_at",
+	"updated_at",
+}
+
+// SortField поле сортировки и ее направление
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Sort порядок сортировки профилей: поля через запятую, "-" перед полем задает
+// сортировку по убыванию, например -age,surname
+type Sort struct {
+	Fields []SortField
+}
+
+func (s *Sort) UnmarshalText(text []byte) error {
+	s.Fields = s.Fields[:0]
+
+	for _, part := range strings.Split(string(text), ",") {
+		part = strings.TrimSpace(part)
+		field, desc := strings.CutPrefix(part, "-")
+		if field == "" {
+			return fmt.Errorf("empty sort field in %q", text)
+		}
+		if !slices.Contains(SortFields, field) {
+			return fmt.Errorf("unknown sort field %q, allowed: %s", field, strings.Join(SortFields, ", "))
+		}
+		if slices.ContainsFunc(s.Fields, func(f SortField) bool { return f.Field == field }) {
+			return fmt.Errorf("duplicate sort field %q", field)
+		}
+
+		s.Fields = append(s.Fields, SortField{Field: field, Desc: desc})
+	}
+
+	return nil
+}
+
+// String возвращает порядок сортировки в формате параметра sort
+func (s Sort) String() string {
+	parts := make([]string, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		if f.Desc {
+			parts = append(parts, "-"+f.Field)
+			continue
+		}
+		parts = append(parts, f.Field)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// WithTieBreak дополняет порядок сортировкой по id, чтобы порядок профилей с равными
+// значениями полей был одинаковым между страницами
+func (s *Sort) WithTieBreak() []SortField {
+	var fields []SortField
+	if s != nil {
+		fields = slices.Clone(s.Fields)
+	}
+	if !slices.ContainsFunc(fields, func(f SortField) bool { return f.Field == "id" }) {
+		fields = append(fields, SortField{Field: "id"})
+	}
+
+	return fields
+}
+
+// SortValue значение поля сортировки профиля в текстовом виде; nil означает отсутствие значения
+func SortValue(p *models.FullPerson, field string) *string {
+	var v string
+	switch field {
+	case "id":
+		v = strconv.FormatInt(p.ID, 10)
+	case "name":
+		v = p.Name
+	case "surname":
+		v = p.Surname
+	case "patronymic":
+		if p.Patronymic == nil {
+			return nil
+		}
+		v = *p.Patronymic
+	case "age":
+		if p.Age == nil {
+			return nil
+		}
+		v = strconv.FormatInt(*p.Age, 10)
+	case "gender":
+		if p.Gender == nil {
+			return nil
+		}
+		v = string(*p.Gender)
+	case "nationality":
+		if p.Nationality == nil {
+			return nil
+		}
+		v = *p.Nationality
+	case "created_at":
+		v = p.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		v = p.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		return nil
+	}
+
+	return &v
+}
+
+// ParseSortValue восстанавливает значение поля сортировки из текстового вида SortValue
+func ParseSortValue(field string, v *string) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch field {
+	case "id", "age":
+		return strconv.ParseInt(*v, 10, 64)
+	case "gender":
+		return models.Gender(*v), nil
+	case "created_at", "updated_at":
+		return time.Parse(time.RFC3339Nano, *v)
+	case "name", "surname", "patronymic", "nationality":
+		return *v, nil
+	}
+
+	return nil, fmt.Errorf("unknown sort field %q", field)
+}
@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/lib/breaker"
+)
+
+const (
+	headerRetryAfter     = "Retry-After"
+	headerRateLimitReset = "X-Rate-Limit-Reset"
+)
+
+type Options struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// Client повторяет запросы при сетевых ошибках, 429 и 5xx с экспоненциальной задержкой
+// и не обращается к сервису, пока его предохранитель разомкнут. Задержка из Retry-After
+// или X-Rate-Limit-Reset ограничивается MaxDelay: запрос повторяется не позже чем через
+// MaxDelay, а если лимит еще не сброшен, попытка засчитывается как обычная неудача.
+type Client struct {
+	client  *http.Client
+	breaker *breaker.Breaker
+	opts    Options
+}
+
+func New(client *http.Client, b *breaker.Breaker, opts Options) *Client {
+	return &Client{
+		client:  client,
+		breaker: b,
+		opts:    opts,
+	}
+}
+
+// Do выполняет запрос без тела. Ответы со статусом, не требующим повтора, возвращаются как есть.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.client.Do(req.Clone(ctx))
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			c.breaker.Success()
+			return resp, nil
+		}
+
+		wait := c.backoff(attempt)
+		if err != nil {
+			err = fmt.Errorf("request failed: %w", err)
+		} else {
+			err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+			if d, ok := retryAfter(resp.Header); ok {
+				wait = min(d, c.opts.MaxDelay)
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if ctx.Err() != nil {
+			c.breaker.Abort()
+			return nil, ctx.Err()
+		}
+
+		if attempt >= c.opts.MaxRetries {
+			c.breaker.Failure()
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt+1, err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			c.breaker.Abort()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.opts.BaseDelay << attempt
+	if d <= 0 || d > c.opts.MaxDelay {
+		d = c.opts.MaxDelay
+	}
+	if d <= 1 {
+		return d
+	}
+
+	half := d / 2
+	return half + rand.N(half)
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// retryAfter читает рекомендуемую задержку из Retry-After (секунды или HTTP-дата)
+// или X-Rate-Limit-Reset (секунды до открытия нового окна)
+func retryAfter(h http.Header) (time.Duration, bool) {
+	if v := h.Get(headerRetryAfter); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(time.Until(t), 0), true
+		}
+	}
+
+	if v := h.Get(headerRateLimitReset); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	return 0, false
+}
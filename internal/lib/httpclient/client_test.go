@@ -0,0 +1,124 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/lib/breaker"
+)
+
+func TestDoCapsRetryAfterAtMaxDelay(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set(headerRetryAfter, "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := New(srv.Client(), breaker.New("test", 1, time.Minute), Options{
+		MaxRetries: 1,
+		BaseDelay:  time.Millisecond,
+		MaxDelay:   20 * time.Millisecond,
+	})
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Do took %s, want retry after MaxDelay", elapsed)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := New(nil, nil, Options{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second})
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 0, max: 100 * time.Millisecond},
+		{attempt: 1, max: 200 * time.Millisecond},
+		{attempt: 3, max: 800 * time.Millisecond},
+		{attempt: 4, max: time.Second},
+		// сдвиг за пределы int64 не должен давать отрицательную задержку
+		{attempt: 70, max: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempt), func(t *testing.T) {
+			for range 100 {
+				if d := c.backoff(tt.attempt); d < tt.max/2 || d >= tt.max {
+					t.Fatalf("backoff(%d) = %s, want in [%s, %s)", tt.attempt, d, tt.max/2, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "none", header: http.Header{}},
+		{name: "seconds", header: http.Header{headerRetryAfter: {"5"}}, want: 5 * time.Second, wantOK: true},
+		{name: "zero", header: http.Header{headerRetryAfter: {"0"}}, want: 0, wantOK: true},
+		{name: "past date", header: http.Header{headerRetryAfter: {"Mon, 02 Jan 2006 15:04:05 GMT"}}, want: 0, wantOK: true},
+		{name: "negative", header: http.Header{headerRetryAfter: {"-1"}}},
+		{name: "garbage", header: http.Header{headerRetryAfter: {"soon"}}},
+		{name: "rate limit reset", header: http.Header{headerRateLimitReset: {"30"}}, want: 30 * time.Second, wantOK: true},
+		{
+			name:   "invalid retry after falls back to rate limit reset",
+			header: http.Header{headerRetryAfter: {"soon"}, headerRateLimitReset: {"7"}},
+			want:   7 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "retry after wins",
+			header: http.Header{headerRetryAfter: {"1"}, headerRateLimitReset: {"7"}},
+			want:   time.Second,
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.header)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryAfter = %s, %v, want %s, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryAfterFutureDate(t *testing.T) {
+	h := http.Header{headerRetryAfter: {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}}
+
+	got, ok := retryAfter(h)
+	if !ok || got <= 58*time.Minute || got > time.Hour {
+		t.Errorf("retryAfter = %s, %v, want about an hour", got, ok)
+	}
+}
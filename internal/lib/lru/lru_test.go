@@ -0,0 +1,80 @@
+package lru
+
+import "testing"
+
+func TestCache(t *testing.T) {
+	type op struct {
+		add    bool
+		remove bool
+		key    string
+		value  int
+	}
+	tests := []struct {
+		name     string
+		capacity int
+		ops      []op
+		want     map[string]int
+		wantLen  int
+	}{
+		{
+			name:     "evicts least recently added",
+			capacity: 2,
+			ops:      []op{{add: true, key: "a", value: 1}, {add: true, key: "b", value: 2}, {add: true, key: "c", value: 3}},
+			want:     map[string]int{"b": 2, "c": 3},
+			wantLen:  2,
+		},
+		{
+			name:     "get marks as recently used",
+			capacity: 2,
+			ops:      []op{{add: true, key: "a", value: 1}, {add: true, key: "b", value: 2}, {key: "a"}, {add: true, key: "c", value: 3}},
+			want:     map[string]int{"a": 1, "c": 3},
+			wantLen:  2,
+		},
+		{
+			name:     "add replaces value and marks as recently used",
+			capacity: 2,
+			ops:      []op{{add: true, key: "a", value: 1}, {add: true, key: "b", value: 2}, {add: true, key: "a", value: 10}, {add: true, key: "c", value: 3}},
+			want:     map[string]int{"a": 10, "c": 3},
+			wantLen:  2,
+		},
+		{
+			name:     "remove",
+			capacity: 2,
+			ops:      []op{{add: true, key: "a", value: 1}, {add: true, key: "b", value: 2}, {remove: true, key: "a"}, {remove: true, key: "x"}},
+			want:     map[string]int{"b": 2},
+			wantLen:  1,
+		},
+		{
+			name:     "zero capacity keeps nothing",
+			capacity: 0,
+			ops:      []op{{add: true, key: "a", value: 1}},
+			want:     map[string]int{},
+			wantLen:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New[string, int](tt.capacity)
+			for _, o := range tt.ops {
+				switch {
+				case o.add:
+					c.Add(o.key, o.value)
+				case o.remove:
+					c.Remove(o.key)
+				default:
+					c.Get(o.key)
+				}
+			}
+
+			if n := c.Len(); n != tt.wantLen {
+				t.Errorf("Len = %d, want %d", n, tt.wantLen)
+			}
+			for _, key := range []string{"a", "b", "c"} {
+				want, wantOK := tt.want[key]
+				if got, ok := c.Get(key); ok != wantOK || got != want {
+					t.Errorf("Get(%q) = %d, %v, want %d, %v", key, got, ok, want, wantOK)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package params
+
+import "strings"
+
+const (
+	IncludeParam      = "include"
+	IncludeProvenance = "provenance"
+)
+
+// Includes проверяет, запрошено ли значение name в параметре include.
+// Значения передаются через запятую или повторением параметра.
+func Includes(values []string, name string) bool {
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if strings.TrimSpace(v) == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
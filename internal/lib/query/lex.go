@@ -0,0 +1,134 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenKeyword
+	tokenString
+	tokenNumber
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// keywords ключевые слова выражения; регистр не учитывается
+var keywords = map[string]bool{
+	"AND":  true,
+	"OR":   true,
+	"NOT":  true,
+	"IS":   true,
+	"NULL": true,
+	"IN":   true,
+}
+
+type token struct {
+	kind tokenKind
+	// text имя поля, ключевое слово в верхнем регистре, оператор или значение строки
+	text   string
+	number int64
+	// pos позиция начала лексемы в символах, с 1
+	pos int
+	raw string
+}
+
+func (t token) errorf(msg string) *SyntaxError {
+	if t.kind == tokenEOF {
+		return &SyntaxError{Pos: t.pos, Msg: "unexpected end of expression, " + msg}
+	}
+
+	return &SyntaxError{Pos: t.pos, Token: t.raw, Msg: msg}
+}
+
+// lex разбивает выражение на лексемы
+func lex(s string) ([]token, error) {
+	var tokens []token
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		emit := func(kind tokenKind, text string) {
+			tokens = append(tokens, token{kind: kind, text: text, pos: start + 1, raw: string(runes[start:i])})
+		}
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			i++
+			emit(tokenLParen, "(")
+		case r == ')':
+			i++
+			emit(tokenRParen, ")")
+		case r == ',':
+			i++
+			emit(tokenComma, ",")
+
+		case strings.ContainsRune("=!<>", r):
+			i++
+			if i < len(runes) && (runes[i] == '=' || r == '<' && runes[i] == '>') {
+				i++
+			}
+			op := string(runes[start:i])
+			switch op {
+			case "<>":
+				op = "!="
+			case "!", "==":
+				return nil, &SyntaxError{Pos: start + 1, Token: op, Msg: "unknown operator"}
+			}
+			emit(tokenOp, op)
+
+		case r == '"':
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, &SyntaxError{Pos: start + 1, Token: string(runes[start:]), Msg: "unterminated string"}
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				} else if runes[i] == '"' {
+					break
+				}
+				b.WriteRune(runes[i])
+			}
+			i++
+			emit(tokenString, b.String())
+
+		case r == '-' || unicode.IsDigit(r):
+			for i++; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+			}
+			n, err := strconv.ParseInt(string(runes[start:i]), 10, 64)
+			if err != nil {
+				return nil, &SyntaxError{Pos: start + 1, Token: string(runes[start:i]), Msg: "invalid number"}
+			}
+			emit(tokenNumber, "")
+			tokens[len(tokens)-1].number = n
+
+		case r == '_' || unicode.IsLetter(r):
+			for i++; i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])); i++ {
+			}
+			word := string(runes[start:i])
+			if upper := strings.ToUpper(word); keywords[upper] {
+				emit(tokenKeyword, upper)
+			} else {
+				emit(tokenIdent, word)
+			}
+
+		default:
+			return nil, &SyntaxError{Pos: start + 1, Token: string(r), Msg: "unexpected character"}
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes) + 1})
+	return tokens, nil
+}
@@ -0,0 +1,330 @@
+// Package query разбирает выражения фильтрации профилей вида
+//
+//	(nationality = "RU" OR nationality = "BY") AND age >= 30 AND NOT gender IS NULL
+//
+// Выражение разбирается в дерево условий, которое хранилище переводит в параметризованный SQL,
+// поэтому значения из выражения никогда не попадают в текст запроса.
+package query
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+const (
+	// MaxLength предельная длина выражения
+	MaxLength = 2000
+	// MaxDepth предельная вложенность скобок и NOT
+	MaxDepth = 32
+)
+
+// Kind тип значений поля
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindTime
+	// KindGender строка male или female
+	KindGender
+)
+
+// Fields поля профиля, доступные в выражении, и типы их значений
+var Fields = map[string]Kind{
+	"id":          KindInt,
+	"name":        KindString,
+	"surname":     KindString,
+	"patronymic":  KindString,
+	"age":         KindInt,
+	"gender":      KindGender,
+	"nationality": KindString,
+	"created_at":  KindTime,
+	"updated_at":  KindTime,
+}
+
+// Op оператор сравнения
+type Op string
+
+const (
+	OpEq Op = "="
+	OpNe Op = "!="
+	OpLt Op = "<"
+	OpLe Op = "<="
+	OpGt Op = ">"
+	OpGe Op = ">="
+)
+
+// Node узел дерева условий: And, Or, Not, Compare, In или IsNull
+type Node interface {
+	node()
+}
+
+type And struct{ Nodes []Node }
+
+type Or struct{ Nodes []Node }
+
+type Not struct{ Node Node }
+
+// Compare сравнение поля со значением; Value имеет тип int64, string или time.Time
+// в зависимости от Fields
+type Compare struct {
+	Field string
+	Op    Op
+	Value any
+}
+
+// In проверка равенства поля одному из значений
+type In struct {
+	Field  string
+	Values []any
+}
+
+// IsNull проверка отсутствия значения поля или, если Not, его наличия
+type IsNull struct {
+	Field string
+	Not   bool
+}
+
+func (And) node()     {}
+func (Or) node()      {}
+func (Not) node()     {}
+func (Compare) node() {}
+func (In) node()      {}
+func (IsNull) node()  {}
+
+// Expr разобранное выражение параметра q
+type Expr struct {
+	Root Node
+	text string
+}
+
+func (e *Expr) UnmarshalText(text []byte) error {
+	root, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	e.Root, e.text = root, string(text)
+	return nil
+}
+
+func (e Expr) String() string {
+	return e.text
+}
+
+// SyntaxError ошибка разбора с позицией (с 1) и текстом ошибочной лексемы
+type SyntaxError struct {
+	Pos   int
+	Token string
+	Msg   string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+	}
+
+	return fmt.Sprintf("position %d near %q: %s", e.Pos, e.Token, e.Msg)
+}
+
+// Parse разбирает выражение
+func Parse(s string) (Node, error) {
+	if len(s) > MaxLength {
+		return nil, &SyntaxError{Pos: MaxLength + 1, Msg: fmt.Sprintf("expression is longer than %d bytes", MaxLength)}
+	}
+
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, t.errorf("expected AND, OR or end of expression")
+	}
+
+	return root, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) keyword(word string) bool {
+	if t := p.peek(); t.kind == tokenKeyword && t.text == word {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) or(depth int) (Node, error) {
+	node, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []Node{node}
+	for p.keyword("OR") {
+		if node, err = p.and(depth); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return Or{Nodes: nodes}, nil
+}
+
+func (p *parser) and(depth int) (Node, error) {
+	node, err := p.not(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []Node{node}
+	for p.keyword("AND") {
+		if node, err = p.not(depth); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return And{Nodes: nodes}, nil
+}
+
+func (p *parser) not(depth int) (Node, error) {
+	if t := p.peek(); depth >= MaxDepth && (t.kind == tokenLParen || t.kind == tokenKeyword && t.text == "NOT") {
+		return nil, t.errorf(fmt.Sprintf("expression is nested deeper than %d levels", MaxDepth))
+	}
+
+	if p.keyword("NOT") {
+		node, err := p.not(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: node}, nil
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		node, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, t.errorf("expected )")
+		}
+		return node, nil
+	}
+
+	return p.condition()
+}
+
+// condition разбирает сравнение, IN или IS [NOT] NULL
+func (p *parser) condition() (Node, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return nil, t.errorf("expected field name")
+	}
+	kind, ok := Fields[t.text]
+	if !ok {
+		return nil, t.errorf("unknown field")
+	}
+	field := t.text
+
+	switch {
+	case p.keyword("IS"):
+		not := p.keyword("NOT")
+		if !p.keyword("NULL") {
+			return nil, p.peek().errorf("expected NULL")
+		}
+		return IsNull{Field: field, Not: not}, nil
+
+	case p.keyword("IN"):
+		if t := p.next(); t.kind != tokenLParen {
+			return nil, t.errorf("expected (")
+		}
+		var values []any
+		for {
+			v, err := p.value(kind)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+
+			t := p.next()
+			if t.kind == tokenRParen {
+				return In{Field: field, Values: values}, nil
+			}
+			if t.kind != tokenComma {
+				return nil, t.errorf("expected , or )")
+			}
+		}
+	}
+
+	op := p.next()
+	if op.kind != tokenOp {
+		return nil, op.errorf("expected comparison operator, IN or IS")
+	}
+
+	v, err := p.value(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return Compare{Field: field, Op: Op(op.text), Value: v}, nil
+}
+
+// value разбирает значение типа kind
+func (p *parser) value(kind Kind) (any, error) {
+	t := p.next()
+	switch {
+	case kind == KindInt && t.kind == tokenNumber:
+		return t.number, nil
+	case kind == KindString && t.kind == tokenString:
+		return t.text, nil
+	case kind == KindGender && t.kind == tokenString:
+		if !models.IsValidGender(models.Gender(t.text)) {
+			return nil, t.errorf(fmt.Sprintf("expected gender %s or %s", models.GenderMale, models.GenderFemale))
+		}
+		return t.text, nil
+	case kind == KindTime && t.kind == tokenString:
+		v, err := time.Parse(time.RFC3339, t.text)
+		if err != nil {
+			return nil, t.errorf("expected time in RFC 3339 format")
+		}
+		return v, nil
+	case kind == KindInt:
+		return nil, t.errorf("expected number")
+	}
+
+	return nil, t.errorf("expected quoted string")
+}
@@ -0,0 +1,138 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		q    string
+		want Node
+	}{
+		{
+			q:    `age <> 30`,
+			want: Compare{Field: "age", Op: OpNe, Value: int64(30)},
+		},
+		{
+			q:    `age >= -1`,
+			want: Compare{Field: "age", Op: OpGe, Value: int64(-1)},
+		},
+		{
+			q:    `name = "a\"b\\c"`,
+			want: Compare{Field: "name", Op: OpEq, Value: `a"b\c`},
+		},
+		{
+			q: `age < 40 and not patronymic is null OR gender = "female"`,
+			want: Or{Nodes: []Node{
+				And{Nodes: []Node{
+					Compare{Field: "age", Op: OpLt, Value: int64(40)},
+					Not{Node: IsNull{Field: "patronymic"}},
+				}},
+				Compare{Field: "gender", Op: OpEq, Value: "female"},
+			}},
+		},
+		{
+			q: `(nationality = "RU" OR nationality = "BY") AND surname IS NOT NULL`,
+			want: And{Nodes: []Node{
+				Or{Nodes: []Node{
+					Compare{Field: "nationality", Op: OpEq, Value: "RU"},
+					Compare{Field: "nationality", Op: OpEq, Value: "BY"},
+				}},
+				IsNull{Field: "surname", Not: true},
+			}},
+		},
+		{
+			q:    `id IN (1, 2)`,
+			want: In{Field: "id", Values: []any{int64(1), int64(2)}},
+		},
+		{
+			q:    `created_at > "2025-01-02T03:04:05Z"`,
+			want: Compare{Field: "created_at", Op: OpGt, Value: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+		},
+		{
+			q:    strings.Repeat("(", MaxDepth) + `age = 1` + strings.Repeat(")", MaxDepth),
+			want: Compare{Field: "age", Op: OpEq, Value: int64(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			got, err := Parse(tt.q)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       string
+		wantPos int
+		wantErr string
+	}{
+		{name: "unknown field", q: `foo = 1`, wantPos: 1, wantErr: `position 1 near "foo": unknown field`},
+		{name: "bang", q: `age ! 1`, wantPos: 5, wantErr: `position 5 near "!": unknown operator`},
+		{name: "double equals", q: `age == 1`, wantPos: 5, wantErr: `position 5 near "==": unknown operator`},
+		{name: "unterminated string", q: `name = "abc`, wantPos: 8, wantErr: `position 8 near "\"abc": unterminated string`},
+		{name: "invalid number", q: `age = -`, wantPos: 7, wantErr: `position 7 near "-": invalid number`},
+		{name: "unexpected character", q: `age = 1 # 2`, wantPos: 9, wantErr: `position 9 near "#": unexpected character`},
+		{name: "string for number", q: `age = "1"`, wantPos: 7, wantErr: `position 7 near "\"1\"": expected number`},
+		{name: "number for string", q: `name = 1`, wantPos: 8, wantErr: `position 8 near "1": expected quoted string`},
+		{name: "unknown gender", q: `gender = "other"`, wantPos: 10, wantErr: `position 10 near "\"other\"": expected gender male or female`},
+		{name: "invalid time", q: `updated_at < "yesterday"`, wantPos: 14, wantErr: `position 14 near "\"yesterday\"": expected time in RFC 3339 format`},
+		{name: "trailing token", q: `age = 1 2`, wantPos: 9, wantErr: `position 9 near "2": expected AND, OR or end of expression`},
+		{name: "missing value", q: `age >`, wantPos: 6, wantErr: `position 6: unexpected end of expression, expected number`},
+		{name: "unclosed paren", q: `(age = 1`, wantPos: 9, wantErr: `position 9: unexpected end of expression, expected )`},
+		{name: "missing null", q: `age IS NOT 1`, wantPos: 12, wantErr: `position 12 near "1": expected NULL`},
+		{name: "unclosed in", q: `id IN (1 2)`, wantPos: 10, wantErr: `position 10 near "2": expected , or )`},
+		{
+			// позиции считаются в символах, а не в байтах
+			name:    "cyrillic",
+			q:       `name = "Иван" AND x = 1`,
+			wantPos: 19,
+			wantErr: `position 19 near "x": unknown field`,
+		},
+		{
+			name:    "too deep parens",
+			q:       strings.Repeat("(", MaxDepth+1) + `age = 1` + strings.Repeat(")", MaxDepth+1),
+			wantPos: MaxDepth + 1,
+			wantErr: `position 33 near "(": expression is nested deeper than 32 levels`,
+		},
+		{
+			name:    "too deep not",
+			q:       strings.Repeat("NOT ", MaxDepth+1) + `age IS NULL`,
+			wantPos: MaxDepth*4 + 1,
+			wantErr: `position 129 near "NOT": expression is nested deeper than 32 levels`,
+		},
+		{
+			name:    "too long",
+			q:       `age = 1` + strings.Repeat(" ", MaxLength),
+			wantPos: MaxLength + 1,
+			wantErr: `position 2001: expression is longer than 2000 bytes`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.q)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse error = %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Pos != tt.wantPos {
+				t.Errorf("Pos = %d, want %d", syntaxErr.Pos, tt.wantPos)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("error = %q, want %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
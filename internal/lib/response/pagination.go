@@ -1,6 +1,7 @@
 package response
 
 import (
+	"net/url"
 	"strconv"
 
 	"github.com/Gustcat/people-info-service/internal/lib/urlbuilder"
@@ -14,9 +15,12 @@ const (
 type Pagination struct {
 	Limit    uint64  `json:"limit"`
 	Offset   uint64  `json:"offset"`
-	Total    uint64  `json:"total"`
+	Total    *uint64 `json:"total,omitempty"`
 	Next     *string `json:"next,omitempty"`
 	Previous *string `json:"previous,omitempty"`
+	// NextCursor и PrevCursor курсоры соседних страниц для параметра cursor
+	NextCursor *string `json:"next_cursor,omitempty"`
+	PrevCursor *string `json:"prev_cursor,omitempty"`
 }
 
 func NewPagination(limit uint64, offset uint64, total uint64, url string) (*Pagination, error) {
@@ -50,8 +54,50 @@ func NewPagination(limit uint64, offset uint64, total uint64, url string) (*Pagi
 	return &Pagination{
 		Limit:    limit,
 		Offset:   offset,
-		Total:    total,
+		Total:    &total,
 		Next:     next,
 		Previous: prev,
 	}, nil
 }
+
+// NewCursorPagination пагинация по курсорам: ссылки next и previous ведут на страницы
+// после и до текущей. total равен nil, если общее число не подсчитывалось.
+func NewCursorPagination(limit uint64, total *uint64, rawURL string, next, prev *string) (*Pagination, error) {
+	p := &Pagination{
+		Limit:      limit,
+		Total:      total,
+		NextCursor: next,
+		PrevCursor: prev,
+	}
+
+	var err error
+	if next != nil {
+		if p.Next, err = cursorLink(rawURL, limit, *next); err != nil {
+			return nil, err
+		}
+	}
+	if prev != nil {
+		if p.Previous, err = cursorLink(rawURL, limit, *prev); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// cursorLink адрес страницы по курсору; курсор заменяет смещение
+func cursorLink(rawURL string, limit uint64, cursor string) (*string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	q.Del("offset")
+	q.Set("limit", strconv.FormatUint(limit, 10))
+	q.Set("cursor", cursor)
+	u.RawQuery = q.Encode()
+
+	link := u.String()
+	return &link, nil
+}
@@ -0,0 +1,54 @@
+// Package translit транслитерирует кириллические имена латиницей по правилам ICAO Doc 9303,
+// принятым в ГОСТ Р 52535.1-2006 для загранпаспортов: Дмитрий - Dmitrii, Щукина - Shchukina.
+package translit
+
+import (
+	"strings"
+	"unicode"
+)
+
+// latin латинские соответствия строчных кириллических букв русского и украинского алфавитов;
+// мягкий знак опускается
+var latin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "ie", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu",
+	'я': "ia", 'і': "i", 'ї': "i", 'є': "ie", 'ґ': "g",
+}
+
+// Latin возвращает s, в которой кириллические буквы заменены латинскими; прописная буква
+// дает прописную первую букву соответствия (Щ - Shch). Остальные символы не меняются.
+func Latin(s string) string {
+	if !HasCyrillic(s) {
+		return s
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		l, ok := latin[unicode.ToLower(r)]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+
+		if unicode.IsUpper(r) && l != "" {
+			b.WriteString(strings.ToUpper(l[:1]))
+			l = l[1:]
+		}
+		b.WriteString(l)
+	}
+
+	return b.String()
+}
+
+// HasCyrillic сообщает, есть ли в s кириллические буквы
+func HasCyrillic(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package translit
+
+import "testing"
+
+func TestLatin(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Дмитрий", want: "Dmitrii"},
+		{in: "Щукина", want: "Shchukina"},
+		{in: "Юлия", want: "Iuliia"},
+		{in: "Яковлев", want: "Iakovlev"},
+		{in: "Хрущёв", want: "Khrushchev"},
+		{in: "Цой", want: "Tsoi"},
+		{in: "Чайковский", want: "Chaikovskii"},
+		{in: "Жуков", want: "Zhukov"},
+		{in: "Шишкин", want: "Shishkin"},
+		{in: "Объедков", want: "Obieedkov"},
+		{in: "Игорь", want: "Igor"},
+		{in: "Эдуард", want: "Eduard"},
+		{in: "Рыбаков", want: "Rybakov"},
+		{in: "Ігор Їжак Євген Ґалаган", want: "Igor Izhak Ievgen Galagan"},
+		{in: "ЩУКИН", want: "ShchUKIN"},
+		{in: "Анна-Мария O'Hara", want: "Anna-Mariia O'Hara"},
+		{in: "Ivanov", want: "Ivanov"},
+		{in: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := Latin(tt.in); got != tt.want {
+				t.Errorf("Latin(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasCyrillic(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{in: "Иван", want: true},
+		{in: "Ivan Пётр", want: true},
+		{in: "Ivan", want: false},
+		{in: "123", want: false},
+		{in: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := HasCyrillic(tt.in); got != tt.want {
+				t.Errorf("HasCyrillic(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
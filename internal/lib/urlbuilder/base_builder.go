@@ -21,6 +21,21 @@ func BuildWithQueryParams(baseUrl string, params map[string]string) (string, err
 	return u.String(), nil
 }
 
+// Build URL with query parameters, supporting repeated keys
+func BuildWithQueryValues(baseUrl string, params url.Values) (string, error) {
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	for k, vs := range params {
+		q[k] = append(q[k], vs...)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 func BaseURL(r *http.Request) string {
 	var b strings.Builder
 
@@ -36,3 +51,14 @@ func BaseURL(r *http.Request) string {
 
 	return b.String()
 }
+
+// RequestURL адрес запроса вместе с параметрами, например для ссылок пагинации,
+// сохраняющих фильтры и сортировку
+func RequestURL(r *http.Request) string {
+	u := BaseURL(r)
+	if r.URL.RawQuery != "" {
+		u += "?" + r.URL.RawQuery
+	}
+
+	return u
+}
@@ -30,6 +30,9 @@ func ErrorMessage(errs validator.ValidationErrors) string {
 		case "oneof":
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be one of [%s]", err.Field(), err.Param()))
 
+		case "iso3166_1_alpha2":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be an ISO 3166-1 alpha-2 country code", err.Field()))
+
 		default:
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is invalid", err.Field()))
 		}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+type EnrichmentStatus string
+
+const (
+	EnrichmentStatusPending EnrichmentStatus = "pending"
+	EnrichmentStatusDone    EnrichmentStatus = "done"
+	EnrichmentStatusFailed  EnrichmentStatus = "failed"
+)
+
+// EnrichmentJob задача фонового обогащения профиля
+type EnrichmentJob struct {
+	ID          int64   `db:"id"`
+	PersonID    int64   `db:"person_id"`
+	Attempts    int     `db:"attempts"`
+	CountryHint *string `db:"country_hint"`
+	// OverwriteManual разрешает заменить атрибуты, заданные вручную
+	OverwriteManual bool `db:"overwrite_manual"`
+}
+
+// SourceManual источник атрибута, заданного вручную через API
+const SourceManual = "manual"
+
+// Provenance происхождение значения атрибута профиля
+type Provenance struct {
+	Source      string     `db:"source" json:"source" example:"genderize"`
+	Confidence  *float64   `db:"confidence" json:"confidence,omitempty" example:"0.98"`
+	SampleCount *int64     `db:"sample_count" json:"sample_count,omitempty" example:"12345"`
+	FetchedAt   *time.Time `db:"fetched_at" json:"fetched_at,omitempty"`
+	// CountryID страна, для которой источник уточнял значение
+	CountryID *string `db:"country_id" json:"country_id,omitempty" example:"RU"`
+
+	// RejectedValue значение источника, отброшенное из-за низкой достоверности
+	RejectedValue  *string `db:"rejected_value" json:"rejected_value,omitempty" example:"female"`
+	RejectedReason *string `db:"rejected_reason" json:"rejected_reason,omitempty" enums:"low_probability,low_count"`
+}
+
+// EnrichmentChange изменение атрибута при повторном обогащении
+type EnrichmentChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// EnrichmentReport результат повторного обогащения профиля
+type EnrichmentReport struct {
+	ID      int64              `json:"id"`
+	Changes []EnrichmentChange `json:"changes"`
+	Skipped []string           `json:"skipped,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// EnrichmentUpdate измененные атрибуты профиля вместе с их происхождением.
+// NationalityCandidates заменяют сохраненные кандидаты, если не равны nil.
+type EnrichmentUpdate struct {
+	PersonUpdate
+	Provenance            map[string]*Provenance
+	NationalityCandidates []NationalityCandidate
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// HistoryAction вид изменения профиля
+type HistoryAction string
+
+const (
+	HistoryActionCreate HistoryAction = "create"
+	HistoryActionUpdate HistoryAction = "update"
+	HistoryActionDelete HistoryAction = "delete"
+	// HistoryActionRestore восстановление удаленного профиля
+	HistoryActionRestore HistoryAction = "restore"
+	// HistoryActionPurge окончательное удаление профиля по истечении срока хранения
+	HistoryActionPurge HistoryAction = "purge"
+)
+
+// HistorySource канал, через который профиль был изменен
+type HistorySource string
+
+const (
+	HistorySourceAPI        HistorySource = "api"
+	HistorySourceImport     HistorySource = "import"
+	HistorySourceEnrichment HistorySource = "enrichment"
+	HistorySourceRetention  HistorySource = "retention"
+)
+
+// HistoryChange старое и новое значение поля профиля; nil означает отсутствие значения
+type HistoryChange struct {
+	Field string `json:"field" example:"nationality"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// HistoryEntry запись журнала изменений профиля
+type HistoryEntry struct {
+	ID        int64           `db:"id" json:"id"`
+	PersonID  int64           `db:"person_id" json:"person_id"`
+	Action    HistoryAction   `db:"action" json:"action" enums:"create,update,delete,restore,purge"`
+	Changes   []HistoryChange `db:"changes" json:"changes"`
+	Actor     *string         `db:"actor" json:"actor,omitempty" example:"compliance@example.com"`
+	Source    HistorySource   `db:"source" json:"source" enums:"api,import,enrichment,retention"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
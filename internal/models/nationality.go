@@ -0,0 +1,7 @@
+package models
+
+// NationalityCandidate возможная национальность с вероятностью по данным источника
+type NationalityCandidate struct {
+	CountryID   string  `db:"country_id" json:"country_id" example:"RU"`
+	Probability float64 `db:"probability" json:"probability" example:"0.45"`
+}
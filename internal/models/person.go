@@ -1,9 +1,14 @@
 package models
 
+import "time"
+
 type Person struct {
 	Name       string  `db:"name" json:"name" validate:"required,min=2,max=100"`
 	Surname    string  `db:"surname" json:"surname" validate:"required,min=2,max=100"`
 	Patronymic *string `db:"patronymic" json:"patronymic" validate:"omitempty,min=2,max=100"`
+
+	// CountryHint страна (ISO 3166-1 alpha-2), для которой уточняются возраст и пол
+	CountryHint *string `db:"-" json:"country_hint,omitempty" validate:"omitempty,iso3166_1_alpha2" example:"RU"`
 }
 
 type EnrichmentPerson struct {
@@ -11,6 +16,11 @@ type EnrichmentPerson struct {
 	Age         *int64  `db:"age" json:"age" validate:"omitempty,gte=0,lte=130"`
 	Gender      *Gender `db:"gender" json:"gender" validate:"omitempty,oneof=male female"`
 	Nationality *string `db:"nationality" json:"nationality" validate:"omitempty,min=2,max=100"`
+
+	// Provenance происхождение атрибутов обогащения по их названиям (age, gender, nationality)
+	Provenance map[string]*Provenance `db:"-" json:"provenance,omitempty"`
+	// NationalityCandidates все национальности, предложенные источником
+	NationalityCandidates []NationalityCandidate `db:"-" json:"-"`
 }
 
 type Identifier struct {
@@ -20,6 +30,23 @@ type Identifier struct {
 type FullPerson struct {
 	Identifier
 	EnrichmentPerson
+	EnrichmentStatus EnrichmentStatus `db:"enrichment_status" json:"enrichment_status" enums:"pending,done,failed"`
+	// NameLatin, SurnameLatin и PatronymicLatin ФИО латиницей по правилам ICAO Doc 9303
+	NameLatin       string  `db:"name_latin" json:"name_latin" example:"Dmitrii"`
+	SurnameLatin    string  `db:"surname_latin" json:"surname_latin" example:"Shchukin"`
+	PatronymicLatin *string `db:"patronymic_latin" json:"patronymic_latin"`
+	// Version растет при каждом изменении профиля и передается в заголовке ETag
+	Version   int64     `db:"version" json:"version" example:"3"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	// DeletedAt время удаления; удаленный профиль можно восстановить до окончательной очистки
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+}
+
+// ScoredPerson профиль, найденный поиском по имени, с оценкой совпадения от 0 до 1
+type ScoredPerson struct {
+	FullPerson
+	Score float64 `db:"score" json:"score" example:"0.83"`
 }
 
 type PersonUpdate struct {
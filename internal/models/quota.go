@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// QuotaUsage суточный расход квоты источника обогащения
+type QuotaUsage struct {
+	Provider  string     `db:"provider"`
+	Day       time.Time  `db:"day"`
+	Used      int64      `db:"used"`
+	Limit     *int64     `db:"quota_limit"`
+	Remaining *int64     `db:"remaining"`
+	ResetAt   *time.Time `db:"reset_at"`
+}
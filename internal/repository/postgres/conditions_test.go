@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/Gustcat/people-info-service/internal/lib/filter"
+	"github.com/Gustcat/people-info-service/internal/lib/query"
+	sq "github.com/Masterminds/squirrel"
+)
+
+func toSQL(t *testing.T, cond sq.Sqlizer) (string, []any) {
+	t.Helper()
+
+	sql, args, err := cond.ToSql()
+	if err != nil {
+		t.Fatalf("building SQL: %v", err)
+	}
+
+	return sql, args
+}
+
+func checkSQL(t *testing.T, cond sq.Sqlizer, wantSQL string, wantArgs ...any) {
+	t.Helper()
+
+	sql, args := toSQL(t, cond)
+	if sql != wantSQL {
+		t.Errorf("sql = %s\nwant  %s", sql, wantSQL)
+	}
+	if len(args) != 0 || len(wantArgs) != 0 {
+		if !reflect.DeepEqual(args, wantArgs) {
+			t.Errorf("args = %#v, want %#v", args, wantArgs)
+		}
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	sort := &filter.Sort{Fields: []filter.SortField{{Field: "age", Desc: true}, {Field: "surname"}}}
+
+	tests := []struct {
+		name     string
+		sort     *filter.Sort
+		backward bool
+		want     []string
+	}{
+		{name: "default", want: []string{"id ASC NULLS LAST"}},
+		{name: "forward", sort: sort, want: []string{"age DESC NULLS LAST", "surname ASC NULLS LAST", "id ASC NULLS LAST"}},
+		{name: "backward", sort: sort, backward: true, want: []string{"age ASC NULLS FIRST", "surname DESC NULLS FIRST", "id DESC NULLS FIRST"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orderBy(tt.sort, tt.backward); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orderBy = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	fields := []filter.SortField{{Field: "age", Desc: true}, {Field: "id"}}
+
+	tests := []struct {
+		name     string
+		keyset   *filter.Keyset
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "forward",
+			keyset:   &filter.Keyset{Values: []any{int64(30), int64(7)}},
+			wantSQL:  "(((age < ? OR age IS NULL)) OR (age = ? AND (id > ? OR id IS NULL)))",
+			wantArgs: []any{int64(30), int64(30), int64(7)},
+		},
+		{
+			name:     "backward",
+			keyset:   &filter.Keyset{Values: []any{int64(30), int64(7)}, Backward: true},
+			wantSQL:  "((age > ?) OR (age = ? AND id < ?))",
+			wantArgs: []any{int64(30), int64(30), int64(7)},
+		},
+		{
+			// после профиля без возраста идут только такие же профили
+			name:     "forward after null",
+			keyset:   &filter.Keyset{Values: []any{nil, int64(7)}},
+			wantSQL:  "((age IS NULL AND (id > ? OR id IS NULL)))",
+			wantArgs: []any{int64(7)},
+		},
+		{
+			name:     "backward before null",
+			keyset:   &filter.Keyset{Values: []any{nil, int64(7)}, Backward: true},
+			wantSQL:  "((age IS NOT NULL) OR (age IS NULL AND id < ?))",
+			wantArgs: []any{int64(7)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSQL(t, keysetCondition(fields, tt.keyset), tt.wantSQL, tt.wantArgs...)
+		})
+	}
+}
+
+func TestValuesCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     *filter.Values
+		ignoreCase bool
+		wantSQL    string
+		wantArgs   []any
+	}{
+		{
+			name:     "in",
+			values:   &filter.Values{Items: []string{"RU", "UA"}},
+			wantSQL:  "nationality IN (?,?)",
+			wantArgs: []any{"RU", "UA"},
+		},
+		{
+			name:     "not in",
+			values:   &filter.Values{Items: []string{"RU"}, Not: true},
+			wantSQL:  "(nationality IS NULL OR nationality NOT IN (?))",
+			wantArgs: []any{"RU"},
+		},
+		{
+			name:       "ignore case",
+			values:     &filter.Values{Items: []string{"Ru"}},
+			ignoreCase: true,
+			wantSQL:    "lower(nationality) IN (?)",
+			wantArgs:   []any{"ru"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSQL(t, valuesCondition(nationalityColumn, tt.values, tt.ignoreCase), tt.wantSQL, tt.wantArgs...)
+		})
+	}
+}
+
+func TestPrefixCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefixes   *filter.Values
+		ignoreCase bool
+		wantSQL    string
+		wantArgs   []any
+	}{
+		{
+			name:     "escaped",
+			prefixes: &filter.Values{Items: []string{"Pe_r%", "Iv"}},
+			wantSQL:  "(surname LIKE ? OR surname LIKE ?)",
+			wantArgs: []any{`Pe\_r\%%`, "Iv%"},
+		},
+		{
+			name:       "ignore case",
+			prefixes:   &filter.Values{Items: []string{"petr"}},
+			ignoreCase: true,
+			wantSQL:    "(surname ILIKE ?)",
+			wantArgs:   []any{"petr%"},
+		},
+		{
+			name:     "negated",
+			prefixes: &filter.Values{Items: []string{"Iv"}, Not: true},
+			wantSQL:  "(surname IS NULL OR NOT (surname LIKE ?))",
+			wantArgs: []any{"Iv%"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSQL(t, prefixCondition(surnameColumn, tt.prefixes, tt.ignoreCase), tt.wantSQL, tt.wantArgs...)
+		})
+	}
+}
+
+func TestExprCondition(t *testing.T) {
+	tests := []struct {
+		q        string
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			q:        `(nationality = "RU" OR nationality = "BY") AND age >= 30 AND NOT gender IS NULL`,
+			wantSQL:  "((nationality = ? OR nationality = ?) AND age >= ? AND NOT (gender IS NULL))",
+			wantArgs: []any{"RU", "BY", int64(30)},
+		},
+		{
+			q:        `name IN ("Anna", "Oleg") OR surname != "Ivanov"`,
+			wantSQL:  "(name IN (?,?) OR surname <> ?)",
+			wantArgs: []any{"Anna", "Oleg", "Ivanov"},
+		},
+		{
+			q:        `age < 40 OR patronymic IS NOT NULL`,
+			wantSQL:  "(age < ? OR patronymic IS NOT NULL)",
+			wantArgs: []any{int64(40)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			root, err := query.Parse(tt.q)
+			if err != nil {
+				t.Fatalf("parsing: %v", err)
+			}
+			checkSQL(t, exprCondition(root), tt.wantSQL, tt.wantArgs...)
+		})
+	}
+}
+
+func TestCandidateExists(t *testing.T) {
+	checkSQL(t, candidateExists("UA", 0.2),
+		"EXISTS (SELECT 1 FROM person_nationality_candidate c WHERE c.person_id = person.id AND c.country_id = ? AND c.probability >= ?)",
+		"UA", 0.2)
+}
+
+func TestSearchConditions(t *testing.T) {
+	match, score := searchConditions("Щукин", 0.3)
+
+	const wantScore = "greatest(word_similarity(?, search_text), ts_rank_cd(search_vector, plainto_tsquery('simple', ?), 32), " +
+		"word_similarity(?, search_text), ts_rank_cd(search_vector, plainto_tsquery('simple', ?), 32))"
+	scoreArgs := []any{"щукин", "щукин", "shchukin", "shchukin"}
+
+	checkSQL(t, score, wantScore, scoreArgs...)
+	checkSQL(t, match,
+		"((? <% search_text OR search_vector @@ plainto_tsquery('simple', ?) OR ? <% search_text OR search_vector @@ plainto_tsquery('simple', ?)) AND "+
+			wantScore+" >= ?)",
+		append(append(slices.Clone(scoreArgs), scoreArgs...), 0.3)...)
+}
@@ -0,0 +1,278 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/lib/filter"
+	"github.com/Gustcat/people-info-service/internal/models"
+	"github.com/Gustcat/people-info-service/internal/repository"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/pgxscan"
+)
+
+const (
+	jobTableName = "enrichment_job"
+
+	personIDColumn    = "person_id"
+	attemptsColumn    = "attempts"
+	lastErrorColumn   = "last_error"
+	runAfterColumn    = "run_after"
+	countryHintColumn = "country_hint"
+	overwriteColumn   = "overwrite_manual"
+)
+
+// CreatePending сохраняет профиль без обогащения и ставит задачу на фоновое обогащение
+func (r *Repo) CreatePending(ctx context.Context, person *models.Person) (int64, error) {
+	const op = "repository.postgres.NewRepo.CreatePending"
+
+	var created models.FullPerson
+	err := r.WithTx(ctx, func(ctx context.Context) error {
+		query, args, err := sq.Insert(tableName).
+			PlaceholderFormat(sq.Dollar).
+			Columns(nameColumn, surnameColumn, patronymicColumn, statusColumn).
+			Values(person.Name, person.Surname, person.Patronymic, models.EnrichmentStatusPending).
+			Suffix(returningPerson()).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: building SQL failed: %w", op, err)
+		}
+
+		err = pgxscan.Get(ctx, r.conn(ctx), &created, query, args...)
+		if err != nil {
+			if uniqueViolation(err) {
+				return repository.ErrPersonExists
+			}
+
+			return fmt.Errorf("%s: executing query failed: %w", op, err)
+		}
+
+		if err = r.addHistory(ctx, created.ID, models.HistoryActionCreate, nil, &created); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		query, args, err = sq.Insert(jobTableName).
+			PlaceholderFormat(sq.Dollar).
+			Columns(personIDColumn, countryHintColumn).
+			Values(created.ID, person.CountryHint).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: building SQL failed: %w", op, err)
+		}
+
+		if _, err = r.conn(ctx).Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("%s: executing query failed: %w", op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return created.ID, nil
+}
+
+// EnqueueEnrichment ставит задачи повторного обогащения профилей, подходящих под фильтр,
+// и возвращает их идентификаторы. Профили, у которых уже есть задача, повторно не ставятся.
+func (r *Repo) EnqueueEnrichment(ctx context.Context, filter *filter.PersonFilter, overwriteManual bool) ([]int64, error) {
+	const op = "repository.postgres.NewRepo.EnqueueEnrichment"
+
+	var ids []int64
+	err := r.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if ids, err = r.ListIDs(ctx, filter); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		queued := sq.Select(idColumn).
+			Column("?::boolean", overwriteManual).
+			From(tableName).
+			Where(sq.Eq{idColumn: ids}).
+			Where("NOT EXISTS (SELECT 1 FROM " + jobTableName + " j WHERE j.person_id = person.id)")
+
+		query, args, err := sq.Insert(jobTableName).
+			PlaceholderFormat(sq.Dollar).
+			Columns(personIDColumn, overwriteColumn).
+			Select(queued).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: building SQL failed: %w", op, err)
+		}
+
+		if _, err = r.conn(ctx).Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("%s: executing query failed: %w", op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ClaimEnrichmentJobs забирает готовые к выполнению задачи, откладывая их на время lease,
+// чтобы задачи упавшего обработчика снова стали доступны. Задачи, заблокированные
+// другими обработчиками, пропускаются.
+func (r *Repo) ClaimEnrichmentJobs(ctx context.Context, limit uint64, lease time.Duration) ([]*models.EnrichmentJob, error) {
+	const op = "repository.postgres.NewRepo.ClaimEnrichmentJobs"
+
+	// задачи удаленных профилей ждут восстановления или очистки профиля
+	ready := sq.Select(idColumn).
+		From(jobTableName).
+		Where(runAfterColumn+" <= now()").
+		Where(personIDColumn+" IN (SELECT id FROM "+tableName+" WHERE "+deletedAtColumn+" IS NULL)").
+		OrderBy(runAfterColumn, idColumn).
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	readySQL, readyArgs, err := ready.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	query, args, err := sq.Update(jobTableName).
+		PlaceholderFormat(sq.Dollar).
+		Set(attemptsColumn, sq.Expr("attempts + 1")).
+		Set(runAfterColumn, time.Now().Add(lease)).
+		Where(sq.Expr(fmt.Sprintf("%s IN (%s)", idColumn, readySQL), readyArgs...)).
+		Suffix("RETURNING id, person_id, attempts, country_hint, overwrite_manual").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	jobs := make([]*models.EnrichmentJob, 0, limit)
+	if err = pgxscan.Select(ctx, r.conn(ctx), &jobs, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: query failed: %w", op, err)
+	}
+
+	return jobs, nil
+}
+
+// CompleteEnrichmentJob сохраняет результат обогащения с итоговым статусом и удаляет задачу.
+// Атрибуты, которые источники не вернули, и, если задача не разрешает их заменить,
+// атрибуты, заданные вручную, не изменяются. Задача удаленного профиля остается и выполнится после
+// его восстановления.
+func (r *Repo) CompleteEnrichmentJob(
+	ctx context.Context,
+	job *models.EnrichmentJob,
+	person *models.EnrichmentPerson,
+	status models.EnrichmentStatus,
+) error {
+	const op = "repository.postgres.NewRepo.CompleteEnrichmentJob"
+
+	return r.WithTx(ctx, func(ctx context.Context) error {
+		old, err := r.lockPerson(ctx, job.PersonID, false)
+		if pgxscan.NotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: query failed: %w", op, err)
+		}
+
+		manual := make([]string, 0)
+		if !job.OverwriteManual {
+			manual, err = r.ManualAttributes(ctx, job.PersonID)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		enriched := *old
+		enriched.EnrichmentStatus = status
+		enriched.Version++
+		provenance := maps.Clone(person.Provenance)
+		builder := sq.Update(tableName).
+			PlaceholderFormat(sq.Dollar).
+			Set(statusColumn, status).
+			SetMap(touched).
+			Where(sq.Eq{idColumn: job.PersonID}).
+			Where(notDeleted)
+
+		if person.Age != nil && !slices.Contains(manual, ageColumn) {
+			builder = builder.Set(ageColumn, person.Age)
+			enriched.Age = person.Age
+		}
+		if person.Gender != nil && !slices.Contains(manual, genderColumn) {
+			builder = builder.Set(genderColumn, person.Gender)
+			enriched.Gender = person.Gender
+		}
+		candidates := person.NationalityCandidates
+		if slices.Contains(manual, nationalityColumn) {
+			candidates = nil
+		} else if person.Nationality != nil {
+			builder = builder.Set(nationalityColumn, person.Nationality)
+			enriched.Nationality = person.Nationality
+		}
+		for _, attribute := range manual {
+			delete(provenance, attribute)
+		}
+
+		query, args, err := builder.ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: building SQL failed: %w", op, err)
+		}
+
+		if _, err = r.conn(ctx).Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("%s: executing query failed: %w", op, err)
+		}
+
+		if err = r.saveProvenance(ctx, job.PersonID, provenance); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if candidates != nil {
+			if err = r.saveNationalityCandidates(ctx, job.PersonID, candidates); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		if err = r.addHistory(ctx, job.PersonID, models.HistoryActionUpdate, old, &enriched); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		query, args, err = sq.Delete(jobTableName).
+			PlaceholderFormat(sq.Dollar).
+			Where(sq.Eq{idColumn: job.ID}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: building SQL failed: %w", op, err)
+		}
+
+		if _, err = r.conn(ctx).Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("%s: executing query failed: %w", op, err)
+		}
+
+		return nil
+	})
+}
+
+// RetryEnrichmentJob откладывает задачу до runAfter, сохраняя причину неудачи
+func (r *Repo) RetryEnrichmentJob(ctx context.Context, job *models.EnrichmentJob, lastErr string, runAfter time.Time) error {
+	const op = "repository.postgres.NewRepo.RetryEnrichmentJob"
+
+	query, args, err := sq.Update(jobTableName).
+		PlaceholderFormat(sq.Dollar).
+		Set(lastErrorColumn, lastErr).
+		Set(runAfterColumn, runAfter).
+		Where(sq.Eq{idColumn: job.ID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	if _, err = r.conn(ctx).Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: executing query failed: %w", op, err)
+	}
+
+	return nil
+}
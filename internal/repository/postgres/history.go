@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/lib/audit"
+	"github.com/Gustcat/people-info-service/internal/models"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/pgxscan"
+)
+
+const (
+	historyTableName = "person_history"
+
+	actionColumn    = "action"
+	changesColumn   = "changes"
+	actorColumn     = "actor"
+	createdAtColumn = "created_at"
+)
+
+// historyFields поля профиля, изменения которых попадают в журнал
+var historyFields = []struct {
+	name  string
+	value func(p *models.FullPerson) any
+}{
+	{nameColumn, func(p *models.FullPerson) any { return p.Name }},
+	{surnameColumn, func(p *models.FullPerson) any { return p.Surname }},
+	{patronymicColumn, func(p *models.FullPerson) any { return deref(p.Patronymic) }},
+	{ageColumn, func(p *models.FullPerson) any { return deref(p.Age) }},
+	{genderColumn, func(p *models.FullPerson) any { return deref(p.Gender) }},
+	{nationalityColumn, func(p *models.FullPerson) any { return deref(p.Nationality) }},
+	{statusColumn, func(p *models.FullPerson) any { return p.EnrichmentStatus }},
+	{deletedAtColumn, func(p *models.FullPerson) any { return deref(p.DeletedAt) }},
+}
+
+// History возвращает журнал изменений профиля в порядке их внесения и общее число записей
+func (r *Repo) History(ctx context.Context, personID int64, limit, offset uint64) ([]*models.HistoryEntry, uint64, error) {
+	const op = "repository.postgres.NewRepo.History"
+
+	query, args, err := sq.Select("COUNT(*)").
+		From(historyTableName).
+		Where(sq.Eq{personIDColumn: personID}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	var total uint64
+	if err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("%s: query failed: %w", op, err)
+	}
+
+	entries := make([]*models.HistoryEntry, 0)
+	if offset >= total {
+		return entries, total, nil
+	}
+
+	query, args, err = sq.Select(idColumn, personIDColumn, actionColumn, changesColumn, actorColumn, sourceColumn, createdAtColumn).
+		From(historyTableName).
+		Where(sq.Eq{personIDColumn: personID}).
+		OrderBy(idColumn).
+		Limit(limit).
+		Offset(offset).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	if err = pgxscan.Select(ctx, r.conn(ctx), &entries, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("%s: query failed: %w", op, err)
+	}
+
+	return entries, total, nil
+}
+
+// addHistory записывает изменение профиля с автором и каналом из ctx.
+// Изменение без отличий в отслеживаемых полях не записывается.
+func (r *Repo) addHistory(ctx context.Context, personID int64, action models.HistoryAction, old, new *models.FullPerson) error {
+	changes := personChanges(old, new)
+	if action == models.HistoryActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("encoding history changes failed: %w", err)
+	}
+
+	query, args, err := sq.Insert(historyTableName).
+		PlaceholderFormat(sq.Dollar).
+		Columns(personIDColumn, actionColumn, changesColumn, actorColumn, sourceColumn).
+		Values(personID, action, raw, audit.Actor(ctx), audit.Source(ctx)).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("building SQL failed: %w", err)
+	}
+
+	if _, err = r.conn(ctx).Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("saving history failed: %w", err)
+	}
+
+	return nil
+}
+
+// lockPerson возвращает действующий или, если deleted, удаленный профиль,
+// блокируя его строку до конца транзакции
+func (r *Repo) lockPerson(ctx context.Context, id int64, deleted bool) (*models.FullPerson, error) {
+	var state sq.Sqlizer = notDeleted
+	if deleted {
+		state = sq.NotEq{deletedAtColumn: nil}
+	}
+
+	query, args, err := sq.Select(personColumns...).
+		From(tableName).
+		Where(sq.Eq{idColumn: id}).
+		Where(state).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("building SQL failed: %w", err)
+	}
+
+	var person models.FullPerson
+	if err = pgxscan.Get(ctx, r.conn(ctx), &person, query, args...); err != nil {
+		return nil, err
+	}
+
+	return &person, nil
+}
+
+// personChanges сравнивает отслеживаемые поля профиля до и после изменения;
+// old равен nil при создании профиля, new - при окончательной очистке
+func personChanges(old, new *models.FullPerson) []models.HistoryChange {
+	changes := make([]models.HistoryChange, 0, len(historyFields))
+	for _, f := range historyFields {
+		var o, n any
+		if old != nil {
+			o = f.value(old)
+		}
+		if new != nil {
+			n = f.value(new)
+		}
+		if !sameValue(o, n) {
+			changes = append(changes, models.HistoryChange{Field: f.name, Old: o, New: n})
+		}
+	}
+
+	return changes
+}
+
+// sameValue сравнивает значения полей; время сравнивается как момент, без учета
+// часового пояса и показаний монотонных часов
+func sameValue(a, b any) bool {
+	at, ok := a.(time.Time)
+	if bt, ok2 := b.(time.Time); ok && ok2 {
+		return at.Equal(bt)
+	}
+
+	return a == b
+}
+
+func deref[T any](v *T) any {
+	if v == nil {
+		return nil
+	}
+
+	return *v
+}
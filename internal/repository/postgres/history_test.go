@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+func TestPersonChanges(t *testing.T) {
+	// time.Now содержит показание монотонных часов, а время из базы - нет
+	deletedAt := time.Now()
+	// тот же момент в другом часовом поясе, как его возвращает база
+	sameDeletedAt := deletedAt.Round(0).In(time.FixedZone("MSK", 3*60*60))
+	laterDeletedAt := deletedAt.Add(time.Second)
+	age := int64(42)
+
+	person := func(deletedAt *time.Time, age *int64) *models.FullPerson {
+		p := &models.FullPerson{DeletedAt: deletedAt}
+		p.Name, p.Surname, p.Age = "Ivan", "Petrov", age
+		p.EnrichmentStatus = models.EnrichmentStatusDone
+		return p
+	}
+
+	tests := []struct {
+		name string
+		old  *models.FullPerson
+		new  *models.FullPerson
+		want []models.HistoryChange
+	}{
+		{
+			name: "same time in other location",
+			old:  person(&deletedAt, nil),
+			new:  person(&sameDeletedAt, nil),
+			want: []models.HistoryChange{},
+		},
+		{
+			name: "changed time",
+			old:  person(&deletedAt, nil),
+			new:  person(&laterDeletedAt, nil),
+			want: []models.HistoryChange{{Field: deletedAtColumn, Old: deletedAt, New: laterDeletedAt}},
+		},
+		{
+			name: "set value",
+			old:  person(nil, nil),
+			new:  person(nil, &age),
+			want: []models.HistoryChange{{Field: ageColumn, Old: nil, New: age}},
+		},
+		{
+			name: "purge",
+			old:  person(nil, nil),
+			new:  nil,
+			want: []models.HistoryChange{
+				{Field: nameColumn, Old: "Ivan"},
+				{Field: surnameColumn, Old: "Petrov"},
+				{Field: statusColumn, Old: models.EnrichmentStatusDone},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := personChanges(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("personChanges = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/repository"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	nameEnrichmentTable = "name_enrichment"
+
+	attributeColumn = "attribute"
+	resultColumn    = "result"
+	fetchedAtColumn = "fetched_at"
+)
+
+// GetNameEnrichment возвращает сохраненный результат обогащения для страны countryID
+// (пустая строка - без уточнения страны), полученный не раньше since
+func (r *Repo) GetNameEnrichment(ctx context.Context, name, attribute, countryID string, since time.Time) ([]byte, time.Time, error) {
+	const op = "repository.postgres.NewRepo.GetNameEnrichment"
+
+	builder := sq.Select(resultColumn, fetchedAtColumn).
+		From(nameEnrichmentTable).
+		Where(sq.Eq{nameColumn: name, attributeColumn: attribute, countryIDColumn: countryID}).
+		Where(sq.GtOrEq{fetchedAtColumn: since}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	var (
+		result    []byte
+		fetchedAt time.Time
+	)
+	err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&result, &fetchedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, time.Time{}, repository.ErrEnrichmentNotFound
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%s: executing query failed: %w", op, err)
+	}
+
+	return result, fetchedAt, nil
+}
+
+// SaveNameEnrichment сохраняет результат обогащения, перезаписывая предыдущий
+func (r *Repo) SaveNameEnrichment(ctx context.Context, name, attribute, countryID string, result []byte) error {
+	const op = "repository.postgres.NewRepo.SaveNameEnrichment"
+
+	builder := sq.Insert(nameEnrichmentTable).
+		PlaceholderFormat(sq.Dollar).
+		Columns(nameColumn, attributeColumn, countryIDColumn, resultColumn, fetchedAtColumn).
+		Values(name, attribute, countryID, result, sq.Expr("now()")).
+		Suffix("ON CONFLICT (name, attribute, country_id) DO UPDATE SET result = EXCLUDED.result, fetched_at = EXCLUDED.fetched_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	if _, err = r.conn(ctx).Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: executing query failed: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Gustcat/people-info-service/internal/models"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/pgxscan"
+)
+
+const (
+	candidateTableName = "person_nationality_candidate"
+
+	countryIDColumn   = "country_id"
+	probabilityColumn = "probability"
+)
+
+// NationalityCandidates возвращает кандидаты национальности профиля по убыванию вероятности
+func (r *Repo) NationalityCandidates(ctx context.Context, id int64) ([]models.NationalityCandidate, error) {
+	const op = "repository.postgres.NewRepo.NationalityCandidates"
+
+	query, args, err := sq.Select(countryIDColumn, probabilityColumn).
+		From(candidateTableName).
+		Where(sq.Eq{personIDColumn: id}).
+		OrderBy(probabilityColumn+" DESC", countryIDColumn).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	candidates := make([]models.NationalityCandidate, 0)
+	if err = pgxscan.Select(ctx, r.conn(ctx), &candidates, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: query failed: %w", op, err)
+	}
+
+	return candidates, nil
+}
+
+// saveNationalityCandidates заменяет кандидаты национальности профиля
+func (r *Repo) saveNationalityCandidates(ctx context.Context, personID int64, candidates []models.NationalityCandidate) error {
+	query, args, err := sq.Delete(candidateTableName).
+		PlaceholderFormat(sq.Dollar).
+		Where(sq.Eq{personIDColumn: personID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("building SQL failed: %w", err)
+	}
+
+	if _, err = r.conn(ctx).Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("deleting nationality candidates failed: %w", err)
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	builder := sq.Insert(candidateTableName).
+		PlaceholderFormat(sq.Dollar).
+		Columns(personIDColumn, countryIDColumn, probabilityColumn)
+
+	for _, c := range candidates {
+		builder = builder.Values(personID, c.CountryID, c.Probability)
+	}
+
+	query, args, err = builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("building SQL failed: %w", err)
+	}
+
+	if _, err = r.conn(ctx).Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("saving nationality candidates failed: %w", err)
+	}
+
+	return nil
+}
+
+// candidateExists условие наличия у профиля кандидата национальности с вероятностью не ниже minProbability
+func candidateExists(countryID string, minProbability float64) sq.Sqlizer {
+	return sq.Expr(
+		"EXISTS (SELECT 1 FROM "+candidateTableName+" c WHERE c.person_id = person.id AND c.country_id = ? AND c.probability >= ?)",
+		countryID, minProbability,
+	)
+}
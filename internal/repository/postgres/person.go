@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/Gustcat/people-info-service/internal/lib/filter"
 	"github.com/Gustcat/people-info-service/internal/models"
@@ -24,8 +27,44 @@ const (
 	genderColumn      = "gender"
 	ageColumn         = "age"
 	nationalityColumn = "nationality"
+	statusColumn      = "enrichment_status"
+	deletedAtColumn   = "deleted_at"
+	versionColumn     = "version"
+
+	// генерируемые колонки с ФИО латиницей
+	nameLatinColumn       = "name_latin"
+	surnameLatinColumn    = "surname_latin"
+	patronymicLatinColumn = "patronymic_latin"
 )
 
+// touched при любом изменении профиля увеличивает его версию и время изменения
+var touched = map[string]any{
+	versionColumn:   sq.Expr(versionColumn + " + 1"),
+	updatedAtColumn: sq.Expr("now()"),
+}
+
+// personColumns колонки профиля, из которых собирается models.FullPerson
+var personColumns = []string{
+	idColumn,
+	nameColumn,
+	surnameColumn,
+	patronymicColumn,
+	nameLatinColumn,
+	surnameLatinColumn,
+	patronymicLatinColumn,
+	genderColumn,
+	ageColumn,
+	nationalityColumn,
+	statusColumn,
+	deletedAtColumn,
+	versionColumn,
+	createdAtColumn,
+	updatedAtColumn,
+}
+
+// notDeleted условие, исключающее удаленные профили
+var notDeleted = sq.Eq{deletedAtColumn: nil}
+
 type Repo struct {
 	db *pgxpool.Pool
 }
@@ -51,33 +90,53 @@ func (r *Repo) Create(ctx context.Context, person *models.EnrichmentPerson) (int
 		PlaceholderFormat(sq.Dollar).
 		Columns(nameColumn, surnameColumn, patronymicColumn, genderColumn, ageColumn, nationalityColumn).
 		Values(person.Name, person.Surname, person.Patronymic, person.Gender, person.Age, person.Nationality).
-		Suffix("RETURNING id")
+		Suffix(returningPerson())
 
 	query, args, err := builder.ToSql()
 	if err != nil {
 		return 0, fmt.Errorf("%s: building SQL failed: %w", op, err)
 	}
 
-	var id int64
-	err = r.db.QueryRow(ctx, query, args...).Scan(&id)
-	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return 0, repository.ErrPersonExists
+	var created models.FullPerson
+	err = r.WithTx(ctx, func(ctx context.Context) error {
+		err := pgxscan.Get(ctx, r.conn(ctx), &created, query, args...)
+		if err != nil {
+			if uniqueViolation(err) {
+				return repository.ErrPersonExists
+			}
+
+			return fmt.Errorf("%s: executing query failed: %w", op, err)
+		}
+
+		if err = r.saveProvenance(ctx, created.ID, person.Provenance); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err = r.saveNationalityCandidates(ctx, created.ID, person.NationalityCandidates); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		// снимок в журнале - вставленная строка со значениями, заполненными базой
+		if err = r.addHistory(ctx, created.ID, models.HistoryActionCreate, nil, &created); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
 		}
 
-		return 0, fmt.Errorf("%s: executing query failed: %w", op, err)
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return id, nil
+	return created.ID, nil
 }
 
 func (r *Repo) GetByID(ctx context.Context, id int64) (*models.FullPerson, error) {
 	const op = "repository.postgres.NewRepo.GetByID"
 
-	builder := sq.Select(idColumn, nameColumn, surnameColumn, patronymicColumn, genderColumn, ageColumn, nationalityColumn).
+	builder := sq.Select(personColumns...).
 		From(tableName).
 		Where(sq.Eq{idColumn: id}).
+		Where(notDeleted).
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := builder.ToSql()
@@ -85,14 +144,14 @@ func (r *Repo) GetByID(ctx context.Context, id int64) (*models.FullPerson, error
 		return nil, fmt.Errorf("%s: building SQL failed: %w", op, err)
 	}
 
-	rows, err := r.db.Query(ctx, query, args...)
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: query failed: %w", op, err)
 	}
+	defer rows.Close()
 	if !rows.Next() {
 		return nil, repository.ErrPersonNotFound
 	}
-	defer rows.Close()
 
 	var person models.FullPerson
 	err = pgxscan.ScanOne(&person, rows)
@@ -103,41 +162,82 @@ func (r *Repo) GetByID(ctx context.Context, id int64) (*models.FullPerson, error
 	return &person, nil
 }
 
+// List возвращает страницу профилей, подходящих под фильтр, и их общее число.
+// Страница начинается после filter.Offset профилей или у границы filter.Keyset;
+// при filter.Count=false общее число не подсчитывается и равно 0.
 func (r *Repo) List(ctx context.Context, filter *filter.PersonFilter) ([]*models.FullPerson, uint64, error) {
 	persons := make([]*models.FullPerson, 0)
 
-	builder := sq.Select("COUNT(*)").
+	var total uint64
+	if filter.Count == nil || *filter.Count {
+		builder := sq.Select("COUNT(*)").
+			From("person").
+			PlaceholderFormat(sq.Dollar)
+
+		builder = applyPersonFilters(builder, filter)
+
+		query, args, err := builder.ToSql()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&total)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if filter.Offset != nil && *filter.Offset >= total {
+			return persons, total, nil
+		}
+	}
+
+	backward := filter.Keyset != nil && filter.Keyset.Backward
+
+	builder := sq.Select(personColumns...).
 		From("person").
-		PlaceholderFormat(sq.Dollar)
+		PlaceholderFormat(sq.Dollar).
+		OrderBy(orderBy(filter.Sort, backward)...)
 
 	builder = applyPersonFilters(builder, filter)
 
+	if filter.Keyset != nil {
+		builder = builder.Where(keysetCondition(filter.Sort.WithTieBreak(), filter.Keyset))
+	}
+
+	if filter.Limit != nil {
+		builder = builder.Limit(*filter.Limit)
+	}
+
+	if filter.Offset != nil {
+		builder = builder.Offset(*filter.Offset)
+	}
+
 	query, args, err := builder.ToSql()
 	if err != nil {
 		return nil, 0, err
 	}
 
-	var total uint64
-	err = r.db.QueryRow(ctx, query, args...).Scan(&total)
+	err = pgxscan.Select(ctx, r.conn(ctx), &persons, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	if filter.Offset != nil && *filter.Offset >= total {
-		return persons, 0, nil
+	// предыдущая страница выбирается в обратном порядке
+	if backward {
+		slices.Reverse(persons)
 	}
 
-	builder = sq.Select(
-		idColumn,
-		nameColumn,
-		surnameColumn,
-		patronymicColumn,
-		genderColumn,
-		ageColumn,
-		nationalityColumn).
-		From("person").
+	return persons, total, nil
+}
+
+// ListIDs возвращает идентификаторы профилей, подходящих под фильтр
+func (r *Repo) ListIDs(ctx context.Context, filter *filter.PersonFilter) ([]int64, error) {
+	const op = "repository.postgres.NewRepo.ListIDs"
+
+	builder := sq.Select(idColumn).
+		From(tableName).
 		PlaceholderFormat(sq.Dollar).
-		OrderBy("id")
+		OrderBy(idColumn)
 
 	builder = applyPersonFilters(builder, filter)
 
@@ -149,50 +249,261 @@ func (r *Repo) List(ctx context.Context, filter *filter.PersonFilter) ([]*models
 		builder = builder.Offset(*filter.Offset)
 	}
 
-	query, args, err = builder.ToSql()
+	query, args, err := builder.ToSql()
 	if err != nil {
-		return nil, 0, err
+		return nil, fmt.Errorf("%s: building SQL failed: %w", op, err)
 	}
 
-	err = pgxscan.Select(ctx, r.db, &persons, query, args...)
-	if err != nil {
-		return nil, 0, err
+	ids := make([]int64, 0)
+	if err = pgxscan.Select(ctx, r.conn(ctx), &ids, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: query failed: %w", op, err)
 	}
 
-	return persons, total, nil
+	return ids, nil
+}
+
+// sortColumns колонки, соответствующие полям сортировки filter.SortFields
+var sortColumns = map[string]string{
+	"id":          idColumn,
+	"name":        nameColumn,
+	"surname":     surnameColumn,
+	"patronymic":  patronymicColumn,
+	"age":         ageColumn,
+	"gender":      genderColumn,
+	"nationality": nationalityColumn,
+	"created_at":  createdAtColumn,
+	"updated_at":  updatedAtColumn,
+}
+
+// orderBy выражения ORDER BY для порядка сортировки; профили без значения поля
+// идут в конце при любом направлении. Для выборки предыдущей страницы (backward)
+// порядок обращается.
+func orderBy(sort *filter.Sort, backward bool) []string {
+	fields := sort.WithTieBreak()
+
+	exprs := make([]string, 0, len(fields))
+	for _, f := range fields {
+		direction, nulls := "ASC", "NULLS LAST"
+		if f.Desc {
+			direction = "DESC"
+		}
+		if backward {
+			direction, nulls = reverse[direction], reverse[nulls]
+		}
+		exprs = append(exprs, sortColumns[f.Field]+" "+direction+" "+nulls)
+	}
+
+	return exprs
+}
+
+var reverse = map[string]string{
+	"ASC":        "DESC",
+	"DESC":       "ASC",
+	"NULLS LAST": "NULLS FIRST",
+}
+
+// keysetCondition условие выборки профилей после границы keyset или, если keyset.Backward,
+// до нее в порядке fields. Профиль идет после границы, если совпадает с ней по первым
+// полям и следует за ней по очередному; отсутствующие значения идут в конце, как в orderBy.
+func keysetCondition(fields []filter.SortField, keyset *filter.Keyset) sq.Sqlizer {
+	cond := sq.Or{}
+	for i, f := range fields {
+		beyond := beyondValue(sortColumns[f.Field], f.Desc, keyset.Values[i], keyset.Backward)
+		if beyond == nil {
+			continue
+		}
+
+		and := sq.And{}
+		for j := range i {
+			and = append(and, sq.Eq{sortColumns[fields[j].Field]: keyset.Values[j]})
+		}
+		cond = append(cond, append(and, beyond))
+	}
+
+	return cond
+}
+
+// beyondValue условие, при котором значение колонки следует за value (или, если backward,
+// предшествует ему); nil, если таких значений нет
+func beyondValue(column string, desc bool, value any, backward bool) sq.Sqlizer {
+	if value == nil {
+		if backward {
+			return sq.NotEq{column: nil}
+		}
+		return nil
+	}
+
+	// для убывающей сортировки следующие значения меньше
+	greater := desc == backward
+	if backward {
+		if greater {
+			return sq.Gt{column: value}
+		}
+		return sq.Lt{column: value}
+	}
+
+	if greater {
+		return sq.Or{sq.Gt{column: value}, sq.Eq{column: nil}}
+	}
+	return sq.Or{sq.Lt{column: value}, sq.Eq{column: nil}}
 }
 
 func applyPersonFilters(builder sq.SelectBuilder, filter *filter.PersonFilter) sq.SelectBuilder {
+	if filter.IncludeDeleted == nil || !*filter.IncludeDeleted {
+		builder = builder.Where(notDeleted)
+	}
+	ignoreCase := filter.IgnoreCase != nil && *filter.IgnoreCase
 	if filter.Name != nil {
-		builder = builder.Where(sq.Eq{"name": *filter.Name})
+		builder = builder.Where(valuesCondition(nameColumn, filter.Name, ignoreCase))
 	}
 	if filter.Surname != nil {
-		builder = builder.Where(sq.Eq{"surname": *filter.Surname})
+		builder = builder.Where(valuesCondition(surnameColumn, filter.Surname, ignoreCase))
 	}
 	if filter.Patronymic != nil {
-		builder = builder.Where(sq.Eq{"patronymic": *filter.Patronymic})
+		builder = builder.Where(valuesCondition(patronymicColumn, filter.Patronymic, ignoreCase))
+	}
+	if filter.NamePrefix != nil {
+		builder = builder.Where(prefixCondition(nameColumn, filter.NamePrefix, ignoreCase))
+	}
+	if filter.SurnamePrefix != nil {
+		builder = builder.Where(prefixCondition(surnameColumn, filter.SurnamePrefix, ignoreCase))
+	}
+	if filter.PatronymicPrefix != nil {
+		builder = builder.Where(prefixCondition(patronymicColumn, filter.PatronymicPrefix, ignoreCase))
 	}
 	if filter.AgeMin != nil {
-		builder = builder.Where(sq.GtOrEq{"age": *filter.AgeMin})
+		builder = builder.Where(sq.GtOrEq{ageColumn: *filter.AgeMin})
 	}
 	if filter.AgeMax != nil {
-		builder = builder.Where(sq.LtOrEq{"age": *filter.AgeMax})
+		builder = builder.Where(sq.LtOrEq{ageColumn: *filter.AgeMax})
 	}
 	if filter.Gender != nil {
-		builder = builder.Where(sq.Eq{"surname": *filter.Gender})
+		builder = builder.Where(valuesCondition(genderColumn, &filter.Gender.Values, false))
 	}
 	if filter.Nationality != nil {
-		builder = builder.Where(sq.Eq{"nationality": *filter.Nationality})
+		builder = builder.Where(valuesCondition(nationalityColumn, filter.Nationality, false))
+	}
+	if filter.IsNull != nil {
+		for _, field := range filter.IsNull.Items {
+			builder = builder.Where(sq.Eq{sortColumns[field]: nil})
+		}
+	}
+	if filter.NotNull != nil {
+		for _, field := range filter.NotNull.Items {
+			builder = builder.Where(sq.NotEq{sortColumns[field]: nil})
+		}
+	}
+	if filter.CreatedAfter != nil {
+		builder = builder.Where(sq.Gt{createdAtColumn: *filter.CreatedAfter})
+	}
+	if filter.CreatedBefore != nil {
+		builder = builder.Where(sq.Lt{createdAtColumn: *filter.CreatedBefore})
+	}
+	if filter.UpdatedAfter != nil {
+		builder = builder.Where(sq.Gt{updatedAtColumn: *filter.UpdatedAfter})
+	}
+	if filter.UpdatedBefore != nil {
+		builder = builder.Where(sq.Lt{updatedAtColumn: *filter.UpdatedBefore})
+	}
+	if filter.Q != nil {
+		builder = builder.Where(exprCondition(filter.Q.Root))
+	}
+	if filter.CandidateCountry != nil {
+		minProbability := 0.0
+		if filter.CandidateProbabilityMin != nil {
+			minProbability = *filter.CandidateProbabilityMin
+		}
+		builder = builder.Where(candidateExists(*filter.CandidateCountry, minProbability))
 	}
 
 	return builder
 }
 
-func (r *Repo) Update(ctx context.Context, id int64, personUpdate *models.PersonUpdate) (*models.FullPerson, error) {
+// valuesCondition условие равенства колонки одному из значений; отрицание пропускает
+// и профили без значения
+func valuesCondition(column string, values *filter.Values, ignoreCase bool) sq.Sqlizer {
+	items := values.Items
+	if ignoreCase {
+		column = "lower(" + column + ")"
+		items = make([]string, len(values.Items))
+		for i, v := range values.Items {
+			items[i] = strings.ToLower(v)
+		}
+	}
+
+	if values.Not {
+		return sq.Or{sq.Eq{column: nil}, sq.NotEq{column: items}}
+	}
+
+	return sq.Eq{column: items}
+}
+
+// prefixCondition условие начала колонки с одного из значений; отрицание пропускает
+// и профили без значения
+func prefixCondition(column string, prefixes *filter.Values, ignoreCase bool) sq.Sqlizer {
+	match := sq.Or{}
+	for _, prefix := range prefixes.Items {
+		pattern := likeEscaper.Replace(prefix) + "%"
+		if ignoreCase {
+			match = append(match, sq.ILike{column: pattern})
+		} else {
+			match = append(match, sq.Like{column: pattern})
+		}
+	}
+
+	if prefixes.Not {
+		return sq.Or{sq.Eq{column: nil}, sq.Expr("NOT ?", match)}
+	}
+
+	return match
+}
+
+// likeEscaper экранирует спецсимволы шаблона LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Update изменяет поля профиля. Если задана version, профиль изменяется только
+// в этой версии, иначе возвращается repository.ErrVersionMismatch.
+func (r *Repo) Update(ctx context.Context, id int64, personUpdate *models.PersonUpdate, version *int64) (*models.FullPerson, error) {
 	const op = "repository.postgres.NewRepo.Update"
 
+	person, err := r.updatePerson(ctx, id, &models.EnrichmentUpdate{
+		PersonUpdate: *personUpdate,
+		Provenance:   manualProvenance(personUpdate),
+	}, version, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return person, nil
+}
+
+// ApplyEnrichment сохраняет результат повторного обогащения и завершает обогащение профиля
+func (r *Repo) ApplyEnrichment(ctx context.Context, id int64, update *models.EnrichmentUpdate) (*models.FullPerson, error) {
+	const op = "repository.postgres.NewRepo.ApplyEnrichment"
+
+	person, err := r.updatePerson(ctx, id, update, nil,
+		map[string]any{statusColumn: models.EnrichmentStatusDone})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return person, nil
+}
+
+// updatePerson обновляет поля профиля вместе с происхождением измененных атрибутов обогащения;
+// version, если задана, должна совпадать с текущей версией профиля
+func (r *Repo) updatePerson(
+	ctx context.Context,
+	id int64,
+	update *models.EnrichmentUpdate,
+	version *int64,
+	extra map[string]any,
+) (*models.FullPerson, error) {
+	personUpdate := &update.PersonUpdate
+
 	builder := sq.Update(tableName).
 		PlaceholderFormat(sq.Dollar).
+		SetMap(touched).
 		Where(sq.Eq{idColumn: id})
 
 	// TODO: разобраться с отсутствием Set
@@ -220,44 +531,183 @@ func (r *Repo) Update(ctx context.Context, id int64, personUpdate *models.Person
 		builder = builder.Set("nationality", *personUpdate.Nationality)
 	}
 
-	builder = builder.Suffix("RETURNING id, name, surname, patronymic, gender, age, nationality")
+	if len(extra) > 0 {
+		builder = builder.SetMap(extra)
+	}
+
+	builder = builder.Suffix(returningPerson())
 
 	query, args, err := builder.ToSql()
 	if err != nil {
-		return nil, fmt.Errorf("%s: building SQL failed: %w", op, err)
+		return nil, fmt.Errorf("building SQL failed: %w", err)
 	}
 
-	rows, err := r.db.Query(ctx, query, args...)
+	var person models.FullPerson
+	err = r.WithTx(ctx, func(ctx context.Context) error {
+		old, err := r.lockPerson(ctx, id, false)
+		if pgxscan.NotFound(err) {
+			return repository.ErrPersonNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		if version != nil && *version != old.Version {
+			return repository.ErrVersionMismatch
+		}
+
+		if err = pgxscan.Get(ctx, r.conn(ctx), &person, query, args...); err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+
+		if err = r.saveProvenance(ctx, id, update.Provenance); err != nil {
+			return err
+		}
+
+		if update.NationalityCandidates != nil {
+			if err = r.saveNationalityCandidates(ctx, id, update.NationalityCandidates); err != nil {
+				return err
+			}
+		}
+
+		return r.addHistory(ctx, id, models.HistoryActionUpdate, old, &person)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%s: query failed: %w", op, err)
+		return nil, err
+	}
+
+	return &person, nil
+}
+
+// returningPerson суффикс запроса, возвращающий измененный профиль
+func returningPerson() string {
+	return "RETURNING " + strings.Join(personColumns, ", ")
+}
+
+// Delete помечает профиль удаленным; он исчезает из выборок, но может быть восстановлен
+// до окончательной очистки. Если задана version, удаляется только профиль в этой версии.
+func (r *Repo) Delete(ctx context.Context, id int64, version *int64) error {
+	const op = "repository.postgres.NewRepo.Delete"
+
+	query, args, err := sq.Update(tableName).
+		PlaceholderFormat(sq.Dollar).
+		Set(deletedAtColumn, sq.Expr("now()")).
+		SetMap(touched).
+		Where(sq.Eq{idColumn: id}).
+		Suffix("RETURNING " + deletedAtColumn + ", " + versionColumn).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	return r.WithTx(ctx, func(ctx context.Context) error {
+		old, err := r.lockPerson(ctx, id, false)
+		if pgxscan.NotFound(err) {
+			return repository.ErrPersonNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("%s: query failed: %w", op, err)
+		}
+		if version != nil && *version != old.Version {
+			return repository.ErrVersionMismatch
+		}
+
+		deleted := *old
+		if err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&deleted.DeletedAt, &deleted.Version); err != nil {
+			return fmt.Errorf("%s: executing query failed: %w", op, err)
+		}
+
+		if err = r.addHistory(ctx, id, models.HistoryActionDelete, old, &deleted); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+}
+
+// Restore восстанавливает удаленный профиль
+func (r *Repo) Restore(ctx context.Context, id int64) (*models.FullPerson, error) {
+	const op = "repository.postgres.NewRepo.Restore"
+
+	query, args, err := sq.Update(tableName).
+		PlaceholderFormat(sq.Dollar).
+		Set(deletedAtColumn, nil).
+		SetMap(touched).
+		Where(sq.Eq{idColumn: id}).
+		Suffix(returningPerson()).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: building SQL failed: %w", op, err)
 	}
-	defer rows.Close()
 
 	var person models.FullPerson
-	err = pgxscan.ScanOne(&person, rows)
+	err = r.WithTx(ctx, func(ctx context.Context) error {
+		old, err := r.lockPerson(ctx, id, true)
+		if pgxscan.NotFound(err) {
+			return repository.ErrPersonNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("%s: query failed: %w", op, err)
+		}
+
+		// пока профиль был удален, мог появиться другой с теми же именем и фамилией
+		err = pgxscan.Get(ctx, r.conn(ctx), &person, query, args...)
+		if uniqueViolation(err) {
+			return repository.ErrPersonExists
+		}
+		if err != nil {
+			return fmt.Errorf("%s: query failed: %w", op, err)
+		}
+
+		if err = r.addHistory(ctx, id, models.HistoryActionRestore, old, &person); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%s: scanning failed: %w", op, err)
+		return nil, err
 	}
 
 	return &person, nil
 }
 
-func (r *Repo) Delete(ctx context.Context, id int64) error {
-	const op = "repository.postgres.NewRepo.Delete"
+// Purge окончательно удаляет профили, удаленные раньше deletedBefore, и возвращает их число.
+// Журнал изменений очищенных профилей сохраняется.
+func (r *Repo) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	const op = "repository.postgres.NewRepo.Purge"
 
-	q := sq.Delete(tableName).
+	query, args, err := sq.Delete(tableName).
 		PlaceholderFormat(sq.Dollar).
-		Where(sq.Eq{idColumn: id})
-
-	query, args, err := q.ToSql()
+		Where(sq.Lt{deletedAtColumn: deletedBefore}).
+		Suffix(returningPerson()).
+		ToSql()
 	if err != nil {
-		return fmt.Errorf("%s: building SQL failed: %w", op, err)
+		return 0, fmt.Errorf("%s: building SQL failed: %w", op, err)
 	}
 
-	_, err = r.db.Exec(ctx, query, args...)
+	var purged []*models.FullPerson
+	err = r.WithTx(ctx, func(ctx context.Context) error {
+		if err := pgxscan.Select(ctx, r.conn(ctx), &purged, query, args...); err != nil {
+			return fmt.Errorf("%s: query failed: %w", op, err)
+		}
+
+		for _, person := range purged {
+			if err := r.addHistory(ctx, person.ID, models.HistoryActionPurge, person, nil); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("%s: executing query failed: %w", op, err)
+		return 0, err
 	}
 
-	return nil
+	return int64(len(purged)), nil
+}
+
+// uniqueViolation сообщает, нарушено ли ограничение уникальности
+func uniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
 }
@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Gustcat/people-info-service/internal/models"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/pgxscan"
+)
+
+const (
+	provenanceTableName = "person_attribute_provenance"
+
+	sourceColumn      = "source"
+	confidenceColumn  = "confidence"
+	sampleCountColumn = "sample_count"
+	rejectedColumn    = "rejected_value"
+	reasonColumn      = "rejected_reason"
+	updatedAtColumn   = "updated_at"
+)
+
+// ManualAttributes возвращает атрибуты обогащения профиля, заданные вручную
+func (r *Repo) ManualAttributes(ctx context.Context, id int64) ([]string, error) {
+	const op = "repository.postgres.NewRepo.ManualAttributes"
+
+	query, args, err := sq.Select(attributeColumn).
+		From(provenanceTableName).
+		Where(sq.Eq{personIDColumn: id, sourceColumn: models.SourceManual}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	attributes := make([]string, 0)
+	if err = pgxscan.Select(ctx, r.conn(ctx), &attributes, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: query failed: %w", op, err)
+	}
+
+	return attributes, nil
+}
+
+// LoadProvenance заполняет происхождение атрибутов обогащения у переданных профилей
+func (r *Repo) LoadProvenance(ctx context.Context, persons ...*models.FullPerson) error {
+	const op = "repository.postgres.NewRepo.LoadProvenance"
+
+	if len(persons) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.FullPerson, len(persons))
+	ids := make([]int64, 0, len(persons))
+	for _, person := range persons {
+		byID[person.ID] = person
+		ids = append(ids, person.ID)
+	}
+
+	query, args, err := sq.Select(personIDColumn, attributeColumn, sourceColumn, confidenceColumn, sampleCountColumn,
+		fetchedAtColumn, countryIDColumn, rejectedColumn, reasonColumn).
+		From(provenanceTableName).
+		Where(sq.Eq{personIDColumn: ids}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	var rows []*struct {
+		PersonID  int64  `db:"person_id"`
+		Attribute string `db:"attribute"`
+		models.Provenance
+	}
+	if err = pgxscan.Select(ctx, r.conn(ctx), &rows, query, args...); err != nil {
+		return fmt.Errorf("%s: query failed: %w", op, err)
+	}
+
+	for _, person := range persons {
+		person.Provenance = make(map[string]*models.Provenance)
+	}
+	for _, row := range rows {
+		provenance := row.Provenance
+		byID[row.PersonID].Provenance[row.Attribute] = &provenance
+	}
+
+	return nil
+}
+
+func (r *Repo) saveProvenance(ctx context.Context, personID int64, provenance map[string]*models.Provenance) error {
+	if len(provenance) == 0 {
+		return nil
+	}
+
+	builder := sq.Insert(provenanceTableName).
+		PlaceholderFormat(sq.Dollar).
+		Columns(personIDColumn, attributeColumn, sourceColumn, confidenceColumn, sampleCountColumn,
+			fetchedAtColumn, countryIDColumn, rejectedColumn, reasonColumn, updatedAtColumn).
+		Suffix(`ON CONFLICT (person_id, attribute) DO UPDATE SET
+			source = EXCLUDED.source,
+			confidence = EXCLUDED.confidence,
+			sample_count = EXCLUDED.sample_count,
+			fetched_at = EXCLUDED.fetched_at,
+			country_id = EXCLUDED.country_id,
+			rejected_value = EXCLUDED.rejected_value,
+			rejected_reason = EXCLUDED.rejected_reason,
+			updated_at = EXCLUDED.updated_at`)
+
+	for attribute, p := range provenance {
+		builder = builder.Values(personID, attribute, p.Source, p.Confidence, p.SampleCount,
+			p.FetchedAt, p.CountryID, p.RejectedValue, p.RejectedReason, sq.Expr("now()"))
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("building SQL failed: %w", err)
+	}
+
+	if _, err = r.conn(ctx).Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("saving provenance failed: %w", err)
+	}
+
+	return nil
+}
+
+// manualProvenance отмечает измененные атрибуты обогащения как заданные вручную
+func manualProvenance(personUpdate *models.PersonUpdate) map[string]*models.Provenance {
+	provenance := make(map[string]*models.Provenance, 3)
+	manual := &models.Provenance{Source: models.SourceManual}
+
+	if personUpdate.Age != nil {
+		provenance[ageColumn] = manual
+	}
+	if personUpdate.Gender != nil {
+		provenance[genderColumn] = manual
+	}
+	if personUpdate.Nationality != nil {
+		provenance[nationalityColumn] = manual
+	}
+
+	return provenance
+}
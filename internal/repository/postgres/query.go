@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"github.com/Gustcat/people-info-service/internal/lib/query"
+	sq "github.com/Masterminds/squirrel"
+)
+
+// exprCondition переводит дерево выражения параметра q в условие с параметрами
+func exprCondition(node query.Node) sq.Sqlizer {
+	switch n := node.(type) {
+	case query.And:
+		and := make(sq.And, 0, len(n.Nodes))
+		for _, node := range n.Nodes {
+			and = append(and, exprCondition(node))
+		}
+		return and
+	case query.Or:
+		or := make(sq.Or, 0, len(n.Nodes))
+		for _, node := range n.Nodes {
+			or = append(or, exprCondition(node))
+		}
+		return or
+	case query.Not:
+		return sq.Expr("NOT (?)", exprCondition(n.Node))
+	case query.In:
+		return sq.Eq{sortColumns[n.Field]: n.Values}
+	case query.IsNull:
+		if n.Not {
+			return sq.NotEq{sortColumns[n.Field]: nil}
+		}
+		return sq.Eq{sortColumns[n.Field]: nil}
+	case query.Compare:
+		column := sortColumns[n.Field]
+		switch n.Op {
+		case query.OpEq:
+			return sq.Eq{column: n.Value}
+		case query.OpNe:
+			return sq.NotEq{column: n.Value}
+		case query.OpLt:
+			return sq.Lt{column: n.Value}
+		case query.OpLe:
+			return sq.LtOrEq{column: n.Value}
+		case query.OpGt:
+			return sq.Gt{column: n.Value}
+		case query.OpGe:
+			return sq.GtOrEq{column: n.Value}
+		}
+	}
+
+	// парсер не строит других узлов
+	return sq.Expr("FALSE")
+}
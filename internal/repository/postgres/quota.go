@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/models"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/pgxscan"
+)
+
+const (
+	quotaTableName = "enrichment_quota_usage"
+
+	providerColumn   = "provider"
+	dayColumn        = "day"
+	usedColumn       = "used"
+	quotaLimitColumn = "quota_limit"
+	remainingColumn  = "remaining"
+	resetAtColumn    = "reset_at"
+)
+
+// AddQuotaUsage прибавляет расход квоты источника за сутки и запоминает последний известный остаток
+func (r *Repo) AddQuotaUsage(ctx context.Context, usage *models.QuotaUsage) error {
+	const op = "repository.postgres.NewRepo.AddQuotaUsage"
+
+	query, args, err := sq.Insert(quotaTableName).
+		PlaceholderFormat(sq.Dollar).
+		Columns(providerColumn, dayColumn, usedColumn, quotaLimitColumn, remainingColumn, resetAtColumn, updatedAtColumn).
+		Values(usage.Provider, usage.Day, usage.Used, usage.Limit, usage.Remaining, usage.ResetAt, sq.Expr("now()")).
+		Suffix(`ON CONFLICT (provider, day) DO UPDATE SET
+			used = enrichment_quota_usage.used + EXCLUDED.used,
+			quota_limit = COALESCE(EXCLUDED.quota_limit, enrichment_quota_usage.quota_limit),
+			remaining = COALESCE(EXCLUDED.remaining, enrichment_quota_usage.remaining),
+			reset_at = COALESCE(EXCLUDED.reset_at, enrichment_quota_usage.reset_at),
+			updated_at = EXCLUDED.updated_at`).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	if _, err = r.conn(ctx).Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: executing query failed: %w", op, err)
+	}
+
+	return nil
+}
+
+// QuotaUsage возвращает расход квоты источников за сутки
+func (r *Repo) QuotaUsage(ctx context.Context, day time.Time) ([]*models.QuotaUsage, error) {
+	const op = "repository.postgres.NewRepo.QuotaUsage"
+
+	query, args, err := sq.Select(providerColumn, dayColumn, usedColumn, quotaLimitColumn, remainingColumn, resetAtColumn).
+		From(quotaTableName).
+		Where(sq.Eq{dayColumn: day}).
+		OrderBy(providerColumn).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: building SQL failed: %w", op, err)
+	}
+
+	usages := make([]*models.QuotaUsage, 0)
+	if err = pgxscan.Select(ctx, r.conn(ctx), &usages, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: query failed: %w", op, err)
+	}
+
+	return usages, nil
+}
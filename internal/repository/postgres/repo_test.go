@@ -0,0 +1,344 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/lib/filter"
+	"github.com/Gustcat/people-info-service/internal/lib/query"
+	"github.com/Gustcat/people-info-service/internal/models"
+	"github.com/Gustcat/people-info-service/internal/repository"
+	"github.com/Gustcat/people-info-service/internal/repository/postgres"
+	"github.com/Gustcat/people-info-service/internal/repository/postgres/postgrestest"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func create(t *testing.T, repo *postgres.Repo, name, surname string, age *int64, gender *models.Gender, nationality *string) int64 {
+	t.Helper()
+
+	id, err := repo.Create(context.Background(), &models.EnrichmentPerson{
+		Person:      models.Person{Name: name, Surname: surname},
+		Age:         age,
+		Gender:      gender,
+		Nationality: nationality,
+	})
+	if err != nil {
+		t.Fatalf("creating %s %s: %v", name, surname, err)
+	}
+
+	return id
+}
+
+func ids(persons []*models.FullPerson) []int64 {
+	got := make([]int64, 0, len(persons))
+	for _, p := range persons {
+		got = append(got, p.ID)
+	}
+
+	return got
+}
+
+func TestListFilters(t *testing.T) {
+	repo := postgrestest.New(t)
+	male := models.Gender("male")
+
+	ivanP := create(t, repo, "Ivan", "Petrov", ptr(int64(40)), &male, ptr("RU"))
+	oleg := create(t, repo, "Oleg", "Ivanov", nil, nil, nil)
+	ivanS := create(t, repo, "Ivan", "Sidorov", ptr(int64(40)), &male, ptr("RU"))
+	anna := create(t, repo, "Anna", "Petrova", ptr(int64(30)), nil, nil)
+	taras := create(t, repo, "Taras", "Ivanenko", nil, nil, ptr("UA"))
+
+	parse := func(q string) *query.Expr {
+		t.Helper()
+		var expr query.Expr
+		if err := expr.UnmarshalText([]byte(q)); err != nil {
+			t.Fatalf("parsing %s: %v", q, err)
+		}
+		return &expr
+	}
+
+	tests := []struct {
+		name   string
+		filter filter.PersonFilter
+		want   []int64
+	}{
+		{name: "in", filter: filter.PersonFilter{Nationality: &filter.Values{Items: []string{"RU", "UA"}}}, want: []int64{ivanP, ivanS, taras}},
+		{name: "not in", filter: filter.PersonFilter{Nationality: &filter.Values{Items: []string{"RU"}, Not: true}}, want: []int64{oleg, anna, taras}},
+		{name: "is null", filter: filter.PersonFilter{IsNull: &filter.Fields{Items: []string{"gender"}}}, want: []int64{oleg, anna, taras}},
+		{name: "prefix", filter: filter.PersonFilter{SurnamePrefix: &filter.Values{Items: []string{"petr"}}, IgnoreCase: ptr(true)}, want: []int64{ivanP, anna}},
+		{name: "negated prefix", filter: filter.PersonFilter{SurnamePrefix: &filter.Values{Items: []string{"Iv"}, Not: true}}, want: []int64{ivanP, ivanS, anna}},
+		{name: "q", filter: filter.PersonFilter{Q: parse(`age < 40 OR age IS NULL`)}, want: []int64{oleg, anna, taras}},
+		{name: "q not", filter: filter.PersonFilter{Q: parse(`NOT (name = "Ivan" AND surname != "Petrov") AND id <= ` + itoa(anna))}, want: []int64{ivanP, oleg, anna}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			persons, total, err := repo.List(context.Background(), &tt.filter)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if got := ids(persons); !slices.Equal(got, tt.want) || total != uint64(len(tt.want)) {
+				t.Errorf("ids = %v, total %d, want %v", got, total, tt.want)
+			}
+		})
+	}
+}
+
+func TestListKeyset(t *testing.T) {
+	repo := postgrestest.New(t)
+
+	ivanP := create(t, repo, "Ivan", "Petrov", ptr(int64(40)), nil, nil)
+	oleg := create(t, repo, "Oleg", "Ivanov", nil, nil, nil)
+	ivanS := create(t, repo, "Ivan", "Sidorov", ptr(int64(40)), nil, nil)
+	anna := create(t, repo, "Anna", "Petrova", ptr(int64(30)), nil, nil)
+	want := []int64{ivanP, ivanS, anna, oleg}
+
+	sort := &filter.Sort{Fields: []filter.SortField{{Field: "age", Desc: true}, {Field: "surname"}}}
+	page := func(keyset *filter.Keyset) []*models.FullPerson {
+		t.Helper()
+		persons, _, err := repo.List(context.Background(), &filter.PersonFilter{Sort: sort, Keyset: keyset, Limit: ptr(uint64(1))})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		return persons
+	}
+	boundary := func(p *models.FullPerson) []any {
+		var age any
+		if p.Age != nil {
+			age = *p.Age
+		}
+		return []any{age, p.Surname, p.ID}
+	}
+
+	var forward []*models.FullPerson
+	for persons := page(nil); len(persons) > 0; persons = page(&filter.Keyset{Values: boundary(persons[0])}) {
+		forward = append(forward, persons[0])
+	}
+	if got := ids(forward); !slices.Equal(got, want) {
+		t.Fatalf("forward order = %v, want %v", got, want)
+	}
+
+	var backward []int64
+	last := forward[len(forward)-1]
+	for persons := []*models.FullPerson{last}; len(persons) > 0; persons = page(&filter.Keyset{Values: boundary(persons[0]), Backward: true}) {
+		backward = append([]int64{persons[0].ID}, backward...)
+	}
+	if !slices.Equal(backward, want) {
+		t.Errorf("backward order = %v, want %v", backward, want)
+	}
+}
+
+func TestSearchMatchesLatinName(t *testing.T) {
+	repo := postgrestest.New(t)
+	ctx := context.Background()
+
+	shchukin, err := repo.Create(ctx, &models.EnrichmentPerson{
+		Person: models.Person{Name: "Дмитрий", Surname: "Щукин", Patronymic: ptr("Юрьевич")},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	ivanov := create(t, repo, "Ivan", "Ivanov", nil, nil, nil)
+	petrov := create(t, repo, "Ivan", "Petrov", nil, nil, nil)
+
+	person, err := repo.GetByID(ctx, shchukin)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if person.NameLatin != "Dmitrii" || person.SurnameLatin != "Shchukin" ||
+		person.PatronymicLatin == nil || *person.PatronymicLatin != "Iurevich" {
+		t.Errorf("latin name = %q %q %v, want Dmitrii Shchukin Iurevich", person.NameLatin, person.SurnameLatin, person.PatronymicLatin)
+	}
+
+	for q, want := range map[string]int64{
+		"shchukin":    shchukin,
+		"Щукин":       shchukin,
+		"Иванов":      ivanov,
+		"dmitrii iur": shchukin,
+		"PETROV":      petrov,
+		"ivanovv":     ivanov,
+	} {
+		found, total, err := repo.Search(ctx, &filter.SearchFilter{Q: q, MinScore: ptr(0.3)})
+		if err != nil {
+			t.Fatalf("Search %q: %v", q, err)
+		}
+		if len(found) == 0 || found[0].ID != want || total != uint64(len(found)) {
+			t.Errorf("search %q found %v (total %d), want person %d first", q, found, total, want)
+		}
+	}
+
+	found, _, err := repo.Search(ctx, &filter.SearchFilter{Q: "petrov", MinScore: ptr(0.9)})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != petrov || found[0].Score != 1 {
+		t.Errorf("search with min_score found %v, want only person %d with score 1", found, petrov)
+	}
+}
+
+func TestWithTxRollsBack(t *testing.T) {
+	repo := postgrestest.New(t)
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	var id int64
+	err := repo.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if id, err = repo.Create(ctx, &models.EnrichmentPerson{Person: models.Person{Name: "Ivan", Surname: "Petrov"}}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTx error = %v, want %v", err, errAbort)
+	}
+
+	if _, err = repo.GetByID(ctx, id); !errors.Is(err, repository.ErrPersonNotFound) {
+		t.Errorf("GetByID after rollback: error = %v, want %v", err, repository.ErrPersonNotFound)
+	}
+}
+
+func TestCreateRecordsInsertedRow(t *testing.T) {
+	repo := postgrestest.New(t)
+	ctx := context.Background()
+
+	id := create(t, repo, "Ivan", "Petrov", ptr(int64(40)), nil, nil)
+
+	entries, total, err := repo.History(ctx, id, 10, 0)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if total != 1 || entries[0].Action != models.HistoryActionCreate {
+		t.Fatalf("history = %+v, want one create entry", entries)
+	}
+
+	got := make(map[string]any)
+	for _, c := range entries[0].Changes {
+		if c.Old != nil {
+			t.Errorf("%s old = %v, want null", c.Field, c.Old)
+		}
+		got[c.Field] = c.New
+	}
+	want := map[string]any{"name": "Ivan", "surname": "Petrov", "age": float64(40), "enrichment_status": "done"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("create changes = %v, want %v", got, want)
+	}
+}
+
+func itoa(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+func TestCompleteEnrichmentJobKeepsManualAttributes(t *testing.T) {
+	repo := postgrestest.New(t)
+	ctx := context.Background()
+
+	id, err := repo.CreatePending(ctx, &models.Person{Name: "Ivan", Surname: "Petrov"})
+	if err != nil {
+		t.Fatalf("CreatePending: %v", err)
+	}
+	jobs, err := repo.ClaimEnrichmentJobs(ctx, 10, time.Minute)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("ClaimEnrichmentJobs = %v, %v, want one job", jobs, err)
+	}
+	if _, err = repo.Update(ctx, id, &models.PersonUpdate{Age: ptr(int64(30))}, nil); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	result := &models.EnrichmentPerson{
+		Age:         ptr(int64(42)),
+		Nationality: ptr("RU"),
+		Provenance: map[string]*models.Provenance{
+			"age":         {Source: "agify"},
+			"nationality": {Source: "nationalize"},
+		},
+	}
+	if err = repo.CompleteEnrichmentJob(ctx, jobs[0], result, models.EnrichmentStatusDone); err != nil {
+		t.Fatalf("CompleteEnrichmentJob: %v", err)
+	}
+
+	person, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if person.Age == nil || *person.Age != 30 || person.Nationality == nil || *person.Nationality != "RU" {
+		t.Errorf("age, nationality = %v, %v, want manual 30 and RU", person.Age, person.Nationality)
+	}
+	if person.EnrichmentStatus != models.EnrichmentStatusDone {
+		t.Errorf("status = %q, want %q", person.EnrichmentStatus, models.EnrichmentStatusDone)
+	}
+	if manual, err := repo.ManualAttributes(ctx, id); err != nil || !slices.Equal(manual, []string{"age"}) {
+		t.Errorf("ManualAttributes = %v, %v, want [age]", manual, err)
+	}
+}
+
+func TestCompleteEnrichmentJobWaitsForRestore(t *testing.T) {
+	repo := postgrestest.New(t)
+	ctx := context.Background()
+
+	id, err := repo.CreatePending(ctx, &models.Person{Name: "Ivan", Surname: "Petrov"})
+	if err != nil {
+		t.Fatalf("CreatePending: %v", err)
+	}
+	jobs, err := repo.ClaimEnrichmentJobs(ctx, 10, 0)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("ClaimEnrichmentJobs = %v, %v, want one job", jobs, err)
+	}
+	if err = repo.Delete(ctx, id, nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	result := &models.EnrichmentPerson{Age: ptr(int64(42))}
+	if err = repo.CompleteEnrichmentJob(ctx, jobs[0], result, models.EnrichmentStatusDone); err != nil {
+		t.Fatalf("CompleteEnrichmentJob: %v", err)
+	}
+	if jobs, err = repo.ClaimEnrichmentJobs(ctx, 10, 0); err != nil || len(jobs) != 0 {
+		t.Fatalf("ClaimEnrichmentJobs for deleted person = %v, %v, want none", jobs, err)
+	}
+
+	if _, err = repo.Restore(ctx, id); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if jobs, err = repo.ClaimEnrichmentJobs(ctx, 10, 0); err != nil || len(jobs) != 1 || jobs[0].PersonID != id {
+		t.Errorf("ClaimEnrichmentJobs after restore = %v, %v, want the kept job", jobs, err)
+	}
+}
+
+func TestEnqueueEnrichmentSkipsQueuedPersons(t *testing.T) {
+	repo := postgrestest.New(t)
+	ctx := context.Background()
+
+	pending, err := repo.CreatePending(ctx, &models.Person{Name: "Ivan", Surname: "Petrov"})
+	if err != nil {
+		t.Fatalf("CreatePending: %v", err)
+	}
+	done := create(t, repo, "Oleg", "Ivanov", nil, nil, nil)
+
+	ids, err := repo.EnqueueEnrichment(ctx, &filter.PersonFilter{}, true)
+	if err != nil {
+		t.Fatalf("EnqueueEnrichment: %v", err)
+	}
+	if !slices.Equal(ids, []int64{pending, done}) {
+		t.Errorf("queued ids = %v, want %v", ids, []int64{pending, done})
+	}
+
+	jobs, err := repo.ClaimEnrichmentJobs(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimEnrichmentJobs: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("claimed %d jobs, want one per person", len(jobs))
+	}
+	for _, job := range jobs {
+		if job.OverwriteManual != (job.PersonID == done) {
+			t.Errorf("job for person %d: overwrite_manual = %v", job.PersonID, job.OverwriteManual)
+		}
+	}
+}
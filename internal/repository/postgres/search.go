@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Gustcat/people-info-service/internal/lib/filter"
+	"github.com/Gustcat/people-info-service/internal/lib/translit"
+	"github.com/Gustcat/people-info-service/internal/models"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/pgxscan"
+)
+
+const (
+	searchTextColumn   = "search_text"
+	searchVectorColumn = "search_vector"
+)
+
+// Search ищет действующие профили по имени, фамилии и отчеству, записанным кириллицей
+// или латиницей: по сходству триграмм, которое находит опечатки, и по полнотекстовому
+// совпадению слов. Оценка профиля от 0 до 1 — большая из оценок сходства и совпадения слов;
+// профили с оценкой ниже f.MinScore отбрасываются, остальные упорядочены по ее убыванию.
+// Возвращается также общее число найденных.
+func (r *Repo) Search(ctx context.Context, f *filter.SearchFilter) ([]*models.ScoredPerson, uint64, error) {
+	const op = "repository.postgres.NewRepo.Search"
+
+	minScore := 0.0
+	if f.MinScore != nil {
+		minScore = *f.MinScore
+	}
+	match, score := searchConditions(f.Q, minScore)
+
+	persons := make([]*models.ScoredPerson, 0)
+	var total uint64
+	err := r.WithTx(ctx, func(ctx context.Context) error {
+		// порог оператора <% действует до конца транзакции
+		_, err := r.conn(ctx).Exec(ctx, "SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)",
+			strconv.FormatFloat(minScore, 'f', -1, 64))
+		if err != nil {
+			return fmt.Errorf("%s: setting similarity threshold failed: %w", op, err)
+		}
+
+		query, args, err := sq.Select("COUNT(*)").
+			From(tableName).
+			Where(notDeleted).
+			Where(match).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: building SQL failed: %w", op, err)
+		}
+
+		if err = r.conn(ctx).QueryRow(ctx, query, args...).Scan(&total); err != nil {
+			return fmt.Errorf("%s: query failed: %w", op, err)
+		}
+
+		builder := sq.Select(personColumns...).
+			Column(sq.Alias(score, "score")).
+			From(tableName).
+			Where(notDeleted).
+			Where(match).
+			OrderBy("score DESC", idColumn).
+			PlaceholderFormat(sq.Dollar)
+
+		if f.Limit != nil {
+			builder = builder.Limit(*f.Limit)
+		}
+		if f.Offset != nil {
+			builder = builder.Offset(*f.Offset)
+		}
+
+		query, args, err = builder.ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: building SQL failed: %w", op, err)
+		}
+
+		if err = pgxscan.Select(ctx, r.conn(ctx), &persons, query, args...); err != nil {
+			return fmt.Errorf("%s: query failed: %w", op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return persons, total, nil
+}
+
+// searchConditions условие совпадения профиля с запросом q и оценка совпадения от 0 до 1:
+// большая из оценок сходства триграмм и ранга совпадения слов, приведенного к [0, 1).
+// Условие отбирает профили с оценкой не ниже minScore; операторы <% и @@ в нем позволяют
+// использовать индексы search_text и search_vector, если порог
+// pg_trgm.word_similarity_threshold равен minScore.
+func searchConditions(q string, minScore float64) (match, score sq.Sqlizer) {
+	// кириллический запрос ищется и в транслитерации, чтобы найти имена, записанные латиницей;
+	// латинское написание кириллических имен хранится в search_text и search_vector
+	texts := []string{strings.ToLower(q)}
+	if translit.HasCyrillic(q) {
+		texts = append(texts, strings.ToLower(translit.Latin(q)))
+	}
+
+	candidates := sq.Or{}
+	scores := make([]string, 0, 2*len(texts))
+	args := make([]any, 0, 2*len(texts))
+	for _, text := range texts {
+		candidates = append(candidates,
+			sq.Expr("? <% "+searchTextColumn, text),
+			sq.Expr(searchVectorColumn+" @@ plainto_tsquery('simple', ?)", text),
+		)
+		// нормализация 32 приводит ранг к rank / (rank + 1)
+		scores = append(scores,
+			"word_similarity(?, "+searchTextColumn+")",
+			"ts_rank_cd("+searchVectorColumn+", plainto_tsquery('simple', ?), 32)",
+		)
+		args = append(args, text, text)
+	}
+
+	score = sq.Expr("greatest("+strings.Join(scores, ", ")+")", args...)
+
+	return sq.And{candidates, sq.Expr("? >= ?", score, minScore)}, score
+}
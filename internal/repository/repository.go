@@ -1,8 +1,21 @@
 package repository
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 var (
 	ErrPersonNotFound = errors.New("person not found")
 	ErrPersonExists   = errors.New("person already exists")
+	// ErrVersionMismatch профиль изменен после получения клиентом его версии
+	ErrVersionMismatch = errors.New("person version mismatch")
+
+	ErrEnrichmentNotFound = errors.New("enrichment not found")
 )
+
+// Transactor выполняет несколько вызовов репозитория в одной транзакции.
+// Вызовы репозитория внутри fn должны получать переданный в fn ctx.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,70 @@
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Gustcat/people-info-service/internal/lib/audit"
+	"github.com/Gustcat/people-info-service/internal/models"
+)
+
+type Store interface {
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+// Purger периодически окончательно удаляет профили, удаленные раньше срока хранения
+type Purger struct {
+	log       *slog.Logger
+	store     Store
+	retention time.Duration
+	interval  time.Duration
+}
+
+func New(log *slog.Logger, store Store, retention, interval time.Duration) *Purger {
+	return &Purger{
+		log:       log,
+		store:     store,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run очищает удаленные профили сразу и затем каждые interval до отмены ctx
+func (p *Purger) Run(ctx context.Context) {
+	const op = "retention.Purger.Run"
+	log := p.log.With(slog.String("op", op))
+
+	log.Info("Purge of deleted persons started",
+		slog.Duration("retention", p.retention), slog.Duration("interval", p.interval))
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx, log)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.Info("Purge of deleted persons stopped")
+			return
+		}
+	}
+}
+
+func (p *Purger) purge(ctx context.Context, log *slog.Logger) {
+	ctx = audit.WithSource(ctx, models.HistorySourceRetention)
+
+	purged, err := p.store.Purge(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error("Failed to purge deleted persons", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	if purged > 0 {
+		log.Info("Deleted persons purged", slog.Int64("count", purged))
+	}
+}
@@ -1,6 +1,9 @@
 package swagger
 
 import (
+	"github.com/Gustcat/people-info-service/internal/enrichment/cache"
+	"github.com/Gustcat/people-info-service/internal/enrichment/quota"
+	"github.com/Gustcat/people-info-service/internal/lib/breaker"
 	"github.com/Gustcat/people-info-service/internal/lib/response"
 	"github.com/Gustcat/people-info-service/internal/models"
 )
@@ -29,3 +32,45 @@ type ErrorResponse struct {
 	Status response.Status `json:"status" enums:"error" example:"error"`
 	Error  string          `json:"error"`
 }
+
+type CacheStatsResponse struct {
+	Status response.Status `json:"status"  enums:"ok"`
+	Data   *cache.Stats    `json:"data"`
+}
+
+type BreakersResponse struct {
+	Status response.Status    `json:"status"  enums:"ok"`
+	Data   []breaker.Snapshot `json:"data"`
+}
+
+type QuotaResponse struct {
+	Status response.Status `json:"status"  enums:"ok"`
+	Data   []quota.Usage   `json:"data"`
+}
+
+type EnrichmentReportResponse struct {
+	Status response.Status          `json:"status"  enums:"ok"`
+	Data   *models.EnrichmentReport `json:"data"`
+}
+
+type IDsResponse struct {
+	Status response.Status `json:"status"  enums:"ok"`
+	Data   []int64         `json:"data"`
+}
+
+type NationalityCandidatesResponse struct {
+	Status response.Status               `json:"status"  enums:"ok"`
+	Data   []models.NationalityCandidate `json:"data"`
+}
+
+type HistoryResponse struct {
+	Status     response.Status        `json:"status"  enums:"ok"`
+	Data       []*models.HistoryEntry `json:"data"`
+	Pagination *response.Pagination   `json:"pagination"`
+}
+
+type ScoredPersonsWithPaginationResponse struct {
+	Status     response.Status        `json:"status"  enums:"ok"`
+	Data       []*models.ScoredPerson `json:"data"`
+	Pagination *response.Pagination   `json:"pagination"`
+}
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE name_enrichment (
+    name varchar(50) not null,
+    attribute varchar(20) not null,
+    result jsonb not null,
+    fetched_at timestamptz not null default now(),
+    PRIMARY KEY (name, attribute)
+);
+
+-- +goose Down
+DROP TABLE name_enrichment;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TYPE enrichment_status AS ENUM ('pending', 'done', 'failed');
+
+ALTER TABLE person ADD COLUMN enrichment_status enrichment_status not null default 'done';
+
+CREATE TABLE enrichment_job (
+    id serial primary key,
+    person_id int not null references person (id) on delete cascade,
+    attempts int not null default 0,
+    last_error text,
+    run_after timestamptz not null default now(),
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX enrichment_job_run_after_idx ON enrichment_job (run_after);
+
+-- +goose Down
+DROP TABLE enrichment_job;
+
+ALTER TABLE person DROP COLUMN enrichment_status;
+
+DROP TYPE enrichment_status;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE person_attribute_provenance (
+    person_id int not null references person (id) on delete cascade,
+    attribute varchar(20) not null,
+    source varchar(50) not null,
+    updated_at timestamptz not null default now(),
+    PRIMARY KEY (person_id, attribute)
+);
+
+-- +goose Down
+DROP TABLE person_attribute_provenance;
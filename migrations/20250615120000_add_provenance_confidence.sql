@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE person_attribute_provenance
+    ADD COLUMN confidence double precision,
+    ADD COLUMN sample_count int,
+    ADD COLUMN fetched_at timestamptz;
+
+-- +goose Down
+ALTER TABLE person_attribute_provenance
+    DROP COLUMN confidence,
+    DROP COLUMN sample_count,
+    DROP COLUMN fetched_at;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE person_attribute_provenance
+    ADD COLUMN rejected_value varchar(50),
+    ADD COLUMN rejected_reason varchar(50);
+
+-- +goose Down
+ALTER TABLE person_attribute_provenance
+    DROP COLUMN rejected_value,
+    DROP COLUMN rejected_reason;
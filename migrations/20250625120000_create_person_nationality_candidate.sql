@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE person_nationality_candidate (
+    person_id int not null references person (id) on delete cascade,
+    country_id varchar(2) not null,
+    probability double precision not null,
+    PRIMARY KEY (person_id, country_id)
+);
+
+CREATE INDEX person_nationality_candidate_country_idx ON person_nationality_candidate (country_id, probability);
+
+-- +goose Down
+DROP TABLE person_nationality_candidate;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE name_enrichment DROP CONSTRAINT name_enrichment_pkey;
+ALTER TABLE name_enrichment ADD COLUMN country_id varchar(2) not null default '';
+ALTER TABLE name_enrichment ADD PRIMARY KEY (name, attribute, country_id);
+
+ALTER TABLE person_attribute_provenance ADD COLUMN country_id varchar(2);
+
+ALTER TABLE enrichment_job ADD COLUMN country_hint varchar(2);
+
+-- +goose Down
+ALTER TABLE enrichment_job DROP COLUMN country_hint;
+
+ALTER TABLE person_attribute_provenance DROP COLUMN country_id;
+
+DELETE FROM name_enrichment WHERE country_id <> '';
+ALTER TABLE name_enrichment DROP CONSTRAINT name_enrichment_pkey;
+ALTER TABLE name_enrichment DROP COLUMN country_id;
+ALTER TABLE name_enrichment ADD PRIMARY KEY (name, attribute);
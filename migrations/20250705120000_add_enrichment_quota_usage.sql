@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE enrichment_quota_usage (
+    provider varchar(50) not null,
+    day date not null,
+    used bigint not null default 0,
+    quota_limit bigint,
+    remaining bigint,
+    reset_at timestamptz,
+    updated_at timestamptz not null default now(),
+    PRIMARY KEY (provider, day)
+);
+
+-- +goose Down
+DROP TABLE enrichment_quota_usage;
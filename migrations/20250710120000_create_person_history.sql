@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE person_history (
+    id bigserial PRIMARY KEY,
+    person_id bigint not null,
+    action varchar(20) not null,
+    changes jsonb not null default '[]',
+    actor varchar(255),
+    source varchar(20) not null,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX person_history_person_id_idx ON person_history (person_id, id);
+
+-- +goose Down
+DROP TABLE person_history;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE person ADD COLUMN deleted_at timestamptz;
+
+ALTER TABLE person DROP CONSTRAINT person_name_surname_key;
+CREATE UNIQUE INDEX person_name_surname_key ON person (name, surname) WHERE deleted_at IS NULL;
+
+CREATE INDEX person_deleted_at_idx ON person (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DELETE FROM person WHERE deleted_at IS NOT NULL;
+
+DROP INDEX person_deleted_at_idx;
+
+DROP INDEX person_name_surname_key;
+ALTER TABLE person ADD CONSTRAINT person_name_surname_key UNIQUE (name, surname);
+
+ALTER TABLE person DROP COLUMN deleted_at;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE person ADD COLUMN version bigint not null default 1;
+
+-- +goose Down
+ALTER TABLE person DROP COLUMN version;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE person ADD COLUMN created_at timestamptz not null default now();
+ALTER TABLE person ADD COLUMN updated_at timestamptz not null default now();
+
+CREATE INDEX person_updated_at_idx ON person (updated_at);
+
+-- +goose Down
+ALTER TABLE person DROP COLUMN updated_at;
+ALTER TABLE person DROP COLUMN created_at;
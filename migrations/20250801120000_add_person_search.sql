@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE person ADD COLUMN search_text text GENERATED ALWAYS AS (
+    lower(name || ' ' || surname || coalesce(' ' || patronymic, ''))
+) STORED;
+ALTER TABLE person ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('simple', name || ' ' || surname || coalesce(' ' || patronymic, ''))
+) STORED;
+
+CREATE INDEX person_search_text_idx ON person USING gin (search_text gin_trgm_ops);
+CREATE INDEX person_search_vector_idx ON person USING gin (search_vector);
+
+-- +goose Down
+ALTER TABLE person DROP COLUMN search_vector;
+ALTER TABLE person DROP COLUMN search_text;
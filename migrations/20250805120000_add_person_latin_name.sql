@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+-- translit_latin повторяет internal/lib/translit: правила ICAO Doc 9303 (ГОСТ Р 52535.1-2006)
+CREATE FUNCTION translit_latin(s text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+AS $$
+SELECT translate(
+    replace(replace(replace(replace(replace(replace(replace(replace(replace(replace(
+    replace(replace(replace(replace(replace(replace(replace(replace(replace(replace(s,
+        'Щ', 'Shch'), 'щ', 'shch'),
+        'Ж', 'Zh'), 'ж', 'zh'),
+        'Х', 'Kh'), 'х', 'kh'),
+        'Ц', 'Ts'), 'ц', 'ts'),
+        'Ч', 'Ch'), 'ч', 'ch'),
+        'Ш', 'Sh'), 'ш', 'sh'),
+        'Ъ', 'Ie'), 'ъ', 'ie'),
+        'Ю', 'Iu'), 'ю', 'iu'),
+        'Я', 'Ia'), 'я', 'ia'),
+        'Є', 'Ie'), 'є', 'ie'),
+    'АБВГДЕЁЗИЙКЛМНОПРСТУФЫЭІЇҐабвгдеёзийклмнопрстуфыэіїґЬь',
+    'ABVGDEEZIIKLMNOPRSTUFYEIIGabvgdeeziiklmnoprstufyeiig'
+)
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE person ADD COLUMN name_latin text GENERATED ALWAYS AS (translit_latin(name)) STORED;
+ALTER TABLE person ADD COLUMN surname_latin text GENERATED ALWAYS AS (translit_latin(surname)) STORED;
+ALTER TABLE person ADD COLUMN patronymic_latin text GENERATED ALWAYS AS (translit_latin(patronymic)) STORED;
+
+-- поиск находит профиль по написанию имени любым алфавитом
+ALTER TABLE person DROP COLUMN search_vector;
+ALTER TABLE person DROP COLUMN search_text;
+ALTER TABLE person ADD COLUMN search_text text GENERATED ALWAYS AS (
+    lower(name || ' ' || surname || coalesce(' ' || patronymic, '')
+        || ' ' || translit_latin(name || ' ' || surname || coalesce(' ' || patronymic, '')))
+) STORED;
+ALTER TABLE person ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('simple', name || ' ' || surname || coalesce(' ' || patronymic, '')
+        || ' ' || translit_latin(name || ' ' || surname || coalesce(' ' || patronymic, '')))
+) STORED;
+
+CREATE INDEX person_search_text_idx ON person USING gin (search_text gin_trgm_ops);
+CREATE INDEX person_search_vector_idx ON person USING gin (search_vector);
+
+-- +goose Down
+ALTER TABLE person DROP COLUMN search_vector;
+ALTER TABLE person DROP COLUMN search_text;
+ALTER TABLE person ADD COLUMN search_text text GENERATED ALWAYS AS (
+    lower(name || ' ' || surname || coalesce(' ' || patronymic, ''))
+) STORED;
+ALTER TABLE person ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('simple', name || ' ' || surname || coalesce(' ' || patronymic, ''))
+) STORED;
+
+CREATE INDEX person_search_text_idx ON person USING gin (search_text gin_trgm_ops);
+CREATE INDEX person_search_vector_idx ON person USING gin (search_vector);
+
+ALTER TABLE person DROP COLUMN patronymic_latin;
+ALTER TABLE person DROP COLUMN surname_latin;
+ALTER TABLE person DROP COLUMN name_latin;
+
+DROP FUNCTION translit_latin(text);
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE enrichment_job ADD COLUMN overwrite_manual boolean not null default false;
+
+-- +goose Down
+ALTER TABLE enrichment_job DROP COLUMN overwrite_manual;